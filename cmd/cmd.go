@@ -2,31 +2,87 @@ package cmd
 
 import (
 	"github.com/j-martin/bub/core"
+	"github.com/j-martin/bub/utils"
 	"github.com/urfave/cli"
 	"log"
 	"os"
 )
 
-func BuildCmds() []cli.Command {
-	cfg, err := core.LoadConfiguration()
-	if err != nil {
-		log.Printf("The configuration failed to load... %v", err)
-		core.MustSetupConfig()
-		log.Print("Run 'bub setup' to complete the setup.")
-		os.Exit(0)
+const profileFlag = "profile"
+
+func BuildFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:   profileFlag,
+			EnvVar: "BUB_PROFILE",
+			Usage:  "Configuration profile to use, e.g. when working across multiple organizations.",
+		},
 	}
+}
 
-	manifest, _ := core.LoadManifest()
+// BuildBefore loads the configuration once the global flags are parsed, then the manifest of the current
+// directory which depends on it (e.g. git.baseBranch). The commands share the same references.
+func BuildBefore(cfg *core.Configuration, manifest *core.Manifest) cli.BeforeFunc {
+	return func(c *cli.Context) error {
+		if skipsConfigLoad(c) {
+			return nil
+		}
+		loadedCfg, err := core.LoadConfiguration(c.GlobalString(profileFlag))
+		if err != nil && err != utils.FileDoesNotExist {
+			log.Fatalf("The configuration failed to load: %v\nRun 'bub config validate' to check the config files.", err)
+		}
+		if err != nil {
+			log.Print("The configuration is missing...")
+			core.MustSetupConfig()
+			log.Print("Run 'bub setup' to complete the setup.")
+			os.Exit(0)
+		}
+		*cfg = *loadedCfg
+		loadedManifest, _ := core.LoadManifest(cfg)
+		*manifest = *loadedManifest
+		return nil
+	}
+}
 
+// skipsConfigLoad returns true for the commands which must work with a config that is missing or
+// fails to load, e.g. to create it or to report its errors.
+func skipsConfigLoad(c *cli.Context) bool {
+	cmd := c.App.Command(c.Args().First())
+	if cmd == nil {
+		return false
+	}
+	switch cmd.Name {
+	case "setup":
+		return true
+	case "config":
+		return utils.Contains(c.Args().Get(1), "validate", "migrate")
+	}
+	return false
+}
+
+func BuildCmds(cfg *core.Configuration, manifest *core.Manifest) []cli.Command {
 	return []cli.Command{
 		buildSetupCmd(),
+		buildDoctorCmd(cfg),
 		buildConfigCmd(cfg),
+		{
+			Name:        "credentials",
+			Usage:       "Manage the credentials stored by bub.",
+			Aliases:     []string{"cr"},
+			Subcommands: buildCredentialsCmds(cfg),
+		},
 		{
 			Name:        "repository",
 			Usage:       "Repository related commands.",
 			Aliases:     []string{"r"},
 			Subcommands: buildRepositoryCmds(cfg, manifest),
 		},
+		{
+			Name:        "workspace",
+			Usage:       "Commands for the directory containing the repositories of the organization.",
+			Aliases:     []string{"ws"},
+			Subcommands: buildWorkspaceCmds(cfg),
+		},
 		{
 			Name:        "manifest",
 			Aliases:     []string{"m"},
@@ -61,7 +117,7 @@ func BuildCmds() []cli.Command {
 			Name:        "confluence",
 			Usage:       "Confluence related commands.",
 			Aliases:     []string{"c"},
-			Subcommands: buildConfluenceCmds(cfg),
+			Subcommands: buildConfluenceCmds(cfg, manifest),
 		},
 	}
 }
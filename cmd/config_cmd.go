@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"fmt"
 	"github.com/j-martin/bub/core"
 	"github.com/urfave/cli"
 	"log"
@@ -12,27 +13,109 @@ func buildConfigCmd(cfg *core.Configuration) cli.Command {
 	loadSharedConfigOpt := "load-shared-config"
 	storeSharedConfigOpt := "store-shared-config"
 	preview := "preview"
+	sources := "sources"
 	return cli.Command{
 		Name:  "config",
 		Usage: "Edit your bub config.",
 		Flags: []cli.Flag{
 			cli.BoolFlag{Name: showDefaults, Usage: "Show default config for reference"},
 			cli.BoolFlag{Name: shared, Usage: "Edit shared config."},
-			cli.BoolFlag{Name: loadSharedConfigOpt, Usage: "Load shared config to your config."},
-			cli.BoolFlag{Name: storeSharedConfigOpt, Usage: "Store shared config."},
+			cli.BoolFlag{Name: loadSharedConfigOpt, Usage: "Load the shared config published in 'sharedConfig.source'."},
+			cli.BoolFlag{Name: storeSharedConfigOpt, Usage: "Publish your shared config to 'sharedConfig.source'."},
 			cli.BoolFlag{Name: preview, Usage: "Show/preview the final config."},
+			cli.BoolFlag{Name: sources, Usage: "With --preview, show where each value comes from. Any value can be overridden with BUB_<SECTION>_<FIELD>, e.g. BUB_JIRA_PROJECT."},
+		},
+		Subcommands: []cli.Command{
+			{
+				Name:  "validate",
+				Usage: "Validate the shared, user, profile and repository (.bub.yml) config files.",
+				Action: func(c *cli.Context) error {
+					errs, err := core.ValidateConfiguration(c.GlobalString(profileFlag))
+					if err != nil {
+						return err
+					}
+					for _, e := range errs {
+						fmt.Println(e)
+					}
+					if len(errs) > 0 {
+						return cli.NewExitError(fmt.Sprintf("%v problem(s) found in the config.", len(errs)), 1)
+					}
+					log.Print("The config is valid.")
+					return nil
+				},
+			},
+			{
+				Name:      "get",
+				Usage:     "Print the effective value, e.g. 'bub config get jira.project'.",
+				ArgsUsage: "PATH",
+				Action: func(c *cli.Context) error {
+					if c.NArg() != 1 {
+						return cli.NewExitError("Expected the path of the value, e.g. jira.project", 1)
+					}
+					value, err := core.GetConfigValue(cfg, c.Args().First())
+					if err != nil {
+						return cli.NewExitError(err.Error(), 1)
+					}
+					fmt.Println(value)
+					return nil
+				},
+			},
+			{
+				Name:      "set",
+				Usage:     "Set a value in your config, e.g. 'bub config set jira.board 42'. The comments are preserved.",
+				ArgsUsage: "PATH VALUE",
+				Flags: []cli.Flag{
+					cli.BoolFlag{Name: shared, Usage: "Set the value in the shared config."},
+				},
+				Action: func(c *cli.Context) error {
+					if c.NArg() != 2 {
+						return cli.NewExitError("Expected the path and the value, e.g. jira.board 42. Lists are comma separated.", 1)
+					}
+					configFile := core.ConfigUserFile
+					if c.Bool(shared) {
+						configFile = core.ConfigSharedFile
+					} else if cfg.Profile != "" {
+						configFile = core.GetProfileConfigFile(cfg.Profile)
+					}
+					if err := core.SetConfigValue(configFile, c.Args().Get(0), c.Args().Get(1)); err != nil {
+						return cli.NewExitError(err.Error(), 1)
+					}
+					log.Printf("Updated %v in %v.", c.Args().Get(0), core.GetConfigPath(configFile))
+					return nil
+				},
+			},
+			{
+				Name:  "migrate",
+				Usage: "Update the shared, user and profile config files to the current format. The files are backed up.",
+				Action: func(c *cli.Context) error {
+					return core.MigrateConfiguration()
+				},
+			},
 		},
 		Action: func(c *cli.Context) error {
 			if c.Bool(showDefaults) {
 				print(core.GetConfigString())
 				return nil
 			}
+			if c.Bool(loadSharedConfigOpt) {
+				return core.LoadSharedConfig(cfg)
+			}
+			if c.Bool(storeSharedConfigOpt) {
+				return core.StoreSharedConfig(cfg)
+			}
 			if c.Bool(shared) {
 				return core.EditConfiguration(core.ConfigSharedFile)
 			}
+			if c.Bool(preview) && c.Bool(sources) {
+				return core.ShowConfigSources(cfg)
+			}
 			if c.Bool(preview) {
 				return core.ShowConfig(cfg)
 			}
+			if cfg.Profile != "" {
+				log.Printf("Editing the '%v' profile. Use 'bub config --shared' to edit the shared config.", cfg.Profile)
+				return core.EditProfileConfiguration(cfg.Profile)
+			}
 			log.Printf("Use 'bub config --shared' to edit the shared config.")
 			return core.EditConfiguration(core.ConfigUserFile)
 		},
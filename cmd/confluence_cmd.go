@@ -9,10 +9,18 @@ import (
 	"os"
 )
 
-func buildConfluenceCmds(cfg *core.Configuration) []cli.Command {
+func buildConfluenceCmds(cfg *core.Configuration, manifest *core.Manifest) []cli.Command {
 	noOperation := "noop"
 	cql := "cql"
 	return []cli.Command{
+		{
+			Name:    "publish",
+			Usage:   "Publish the README and the documentation of the repository to the page defined in the manifest (documentation.pageId).",
+			Aliases: []string{"p"},
+			Action: func(c *cli.Context) error {
+				return atlassian.MustInitConfluence(cfg).UpdateDocumentation(manifest)
+			},
+		},
 		{
 			Name:    "open",
 			Usage:   "Open Confluence",
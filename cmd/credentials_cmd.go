@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/j-martin/bub/core"
+	"github.com/j-martin/bub/utils"
+	"github.com/urfave/cli"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+func buildCredentialsCmds(cfg *core.Configuration) []cli.Command {
+	return []cli.Command{
+		{
+			Name:    "list",
+			Aliases: []string{"l"},
+			Usage:   "List the credentials items and where they are stored.",
+			Action: func(c *cli.Context) error {
+				table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(table, "Item\tBackend\tStored")
+				for _, item := range cfg.ListCredentialItems() {
+					stored := "yes"
+					if _, err := cfg.PeekCredential(item); err == core.CredentialNotFound {
+						stored = "no"
+					} else if err != nil {
+						stored = "error"
+					}
+					fmt.Fprintln(table, strings.Join([]string{item, cfg.DescribeCredentialBackend(item), stored}, "\t"))
+				}
+				return table.Flush()
+			},
+		},
+		{
+			Name:      "test",
+			Aliases:   []string{"t"},
+			Usage:     "Check that the credentials can be read from their backend.",
+			ArgsUsage: "[ITEM]...",
+			Action: func(c *cli.Context) error {
+				items := []string(c.Args())
+				if len(items) == 0 {
+					items = cfg.ListCredentialItems()
+				}
+				failures := 0
+				for _, item := range items {
+					_, err := cfg.PeekCredential(item)
+					switch {
+					case err == core.CredentialNotFound:
+						fmt.Printf("MISSING  %v (%v)\n", item, cfg.DescribeCredentialBackend(item))
+						if c.NArg() > 0 {
+							failures++
+						}
+					case err != nil:
+						fmt.Printf("FAILED   %v: %v\n", item, err)
+						failures++
+					default:
+						fmt.Printf("OK       %v (%v)\n", item, cfg.DescribeCredentialBackend(item))
+					}
+				}
+				if failures > 0 {
+					return cli.NewExitError(fmt.Sprintf("%v credential(s) could not be read.", failures), 1)
+				}
+				return nil
+			},
+		},
+		{
+			Name:      "rm",
+			Usage:     "Remove a stored credential.",
+			ArgsUsage: "ITEM",
+			Action: func(c *cli.Context) error {
+				if c.NArg() != 1 {
+					return errors.New("the item must be passed, e.g. 'GitHub Token'. See 'bub credentials list'")
+				}
+				item := c.Args().First()
+				if !utils.AskForConfirmation(fmt.Sprintf("Remove '%v' from %v?", item, cfg.DescribeCredentialBackend(item))) {
+					return nil
+				}
+				return cfg.DeleteCredential(item)
+			},
+		},
+	}
+}
@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"github.com/j-martin/bub/core"
+	"github.com/j-martin/bub/integrations/doctor"
+	"github.com/urfave/cli"
+	"os"
+)
+
+func buildDoctorCmd(cfg *core.Configuration) cli.Command {
+	return cli.Command{
+		Name:  "doctor",
+		Usage: "Check that git, the keyring and every configured integration are working.",
+		Action: func(c *cli.Context) error {
+			results := doctor.New(cfg).Run()
+			if err := doctor.PrintResults(os.Stdout, results); err != nil {
+				return err
+			}
+			if doctor.HasFailures(results) {
+				return cli.NewExitError("Some checks failed.", 1)
+			}
+			return nil
+		},
+	}
+}
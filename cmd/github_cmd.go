@@ -5,6 +5,8 @@ import (
 	"github.com/j-martin/bub/core"
 	"github.com/j-martin/bub/integrations/github"
 	"github.com/urfave/cli"
+	"log"
+	"os"
 )
 
 func buildGitHubCmds(cfg *core.Configuration, manifest *core.Manifest) []cli.Command {
@@ -12,13 +14,18 @@ func buildGitHubCmds(cfg *core.Configuration, manifest *core.Manifest) []cli.Com
 	closed := "closed"
 	role := "role"
 	openAll := "open-all"
+	since := "since"
+	depth := "depth"
+	maxOwners := "max-owners"
+	activeDays := "active-days"
+	offline := "offline"
 	return []cli.Command{
 		{
 			Name:    "repo",
 			Aliases: []string{"r"},
 			Usage:   "Open repo in your browser.",
 			Action: func(c *cli.Context) error {
-				return github.MustInitGitHub(cfg).OpenPage(manifest)
+				return github.MustInitGitHub(cfg).OpenRepository(manifest)
 			},
 		},
 		{
@@ -93,5 +100,67 @@ func buildGitHubCmds(cfg *core.Configuration, manifest *core.Manifest) []cli.Com
 				return nil
 			},
 		},
+		{
+			Name:  "codeowners",
+			Usage: "CODEOWNERS related commands.",
+			Subcommands: []cli.Command{
+				{
+					Name:  "lint",
+					Usage: "Check the CODEOWNERS against the repository and the organization, exits with a non-zero code if any problem is found.",
+					Flags: []cli.Flag{
+						cli.BoolFlag{Name: offline, Usage: "Do not check the users and teams with the GitHub API."},
+					},
+					Action: func(c *cli.Context) error {
+						var gh *github.GitHub
+						if !c.Bool(offline) {
+							gh = github.MustInitGitHub(cfg)
+						}
+						file, problems, err := github.LintCodeOwners(cfg, gh)
+						if err != nil {
+							return cli.NewExitError(err.Error(), 1)
+						}
+						for _, p := range problems {
+							fmt.Printf("%v:%v: %v\n", file, p.Line, p.Message)
+						}
+						if len(problems) > 0 {
+							return cli.NewExitError(fmt.Sprintf("%v problem(s) found in %v.", len(problems), file), 1)
+						}
+						log.Printf("%v is valid.", file)
+						return nil
+					},
+				},
+			},
+		},
+		{
+			Name:  "suggest-owners",
+			Usage: "Suggest the CODEOWNERS of the directories from the history, prints a diff that can be applied with 'git apply'.",
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: since, Value: "1 year ago", Usage: "Only the commits since the date."},
+				cli.IntFlag{Name: depth, Value: 1, Usage: "Number of directory levels, e.g. 2 for services/billing."},
+				cli.IntFlag{Name: maxOwners, Value: 3, Usage: "Maximum number of owners per directory."},
+				cli.IntFlag{Name: activeDays, Value: 90, Usage: "Period in days during which a contributor is considered active."},
+			},
+			Action: func(c *cli.Context) error {
+				suggestion, err := github.SuggestOwners(cfg, github.SuggestOwnersOptions{
+					Since:      c.String(since),
+					Depth:      c.Int(depth),
+					MaxOwners:  c.Int(maxOwners),
+					ActiveDays: c.Int(activeDays),
+				})
+				if err != nil {
+					return cli.NewExitError(err.Error(), 1)
+				}
+				// the report goes to stderr so the diff can be redirected to a file.
+				if err = github.WriteOwnersSuggestion(os.Stderr, suggestion); err != nil {
+					return err
+				}
+				if suggestion.Diff == "" {
+					log.Print("The CODEOWNERS already matches the history.")
+					return nil
+				}
+				fmt.Print(suggestion.Diff)
+				return nil
+			},
+		},
 	}
 }
@@ -1,15 +1,51 @@
 package cmd
 
 import (
+	"fmt"
 	"github.com/j-martin/bub/core"
 	"github.com/j-martin/bub/integrations/github"
+	"github.com/j-martin/bub/utils"
 	"github.com/urfave/cli"
-	"gopkg.in/yaml.v2"
+	"io/ioutil"
 	"log"
 	"os"
+	"path"
+	"strings"
+	"text/tabwriter"
 )
 
+const (
+	workspaceFlag = "workspace"
+	fromGitHub    = "github"
+)
+
+// manifestSourceFlags are the flags used by loadManifests.
+var manifestSourceFlags = []cli.Flag{
+	cli.StringFlag{Name: workspaceFlag, Value: ".", Usage: "Directory containing the repositories."},
+	cli.BoolFlag{Name: fromGitHub, Usage: "Fetch the manifests of every repository of the organization from GitHub instead of the workspace."},
+}
+
+// loadManifests loads the manifests from the workspace directory or from GitHub.
+func loadManifests(c *cli.Context, cfg *core.Configuration) (core.Manifests, error) {
+	if c.Bool(fromGitHub) {
+		return github.MustInitGitHub(cfg).ListManifests()
+	}
+	return core.LoadWorkspaceManifests(c.String(workspaceFlag))
+}
+
 func buildManifestCmds(cfg *core.Configuration) []cli.Command {
+	manifestType := "type"
+	language := "language"
+	platform := "platform"
+	active := "active"
+	inactive := "inactive"
+	format := "format"
+	focus := "focus"
+	all := "all"
+	output := "output"
+	owner := "owner"
+	force := "force"
+	fix := "fix"
 	return []cli.Command{
 		{
 			Name:    "create",
@@ -23,21 +59,269 @@ func buildManifestCmds(cfg *core.Configuration) []cli.Command {
 		{
 			Name:    "validate",
 			Aliases: []string{"v"},
-			Usage:   "Validates the manifest.",
+			Usage:   "Validates the manifest, exits with a non-zero code if it is invalid.",
+			Flags: []cli.Flag{
+				cli.BoolFlag{Name: all, Usage: "Validate every manifest of the repository, e.g. in a monorepo."},
+			},
 			Action: func(c *cli.Context) error {
-				//TODO: Build proper validation
-				manifest, err := core.LoadManifest()
+				root, err := core.InitGit().GetRepositoryRootPath()
 				if err != nil {
-					log.Fatal(err)
-					os.Exit(1)
+					return cli.NewExitError("Must be executed in a repository.", 1)
+				}
+				var errs core.ValidationErrors
+				if c.Bool(all) {
+					errs, err = core.ValidateRepositoryManifests(root)
+				} else {
+					var dir string
+					if dir, err = core.FindManifestDir(root, "."); err == nil {
+						errs, err = core.ValidateManifest(dir)
+					}
 				}
-				manifest.Version = c.String("artifact-version")
-				err = github.MustInitGitHub(cfg).PopulateOwners(manifest)
 				if err != nil {
-					log.Print(err)
+					return cli.NewExitError(err.Error(), 1)
+				}
+				for _, e := range errs {
+					fmt.Println(e)
+				}
+				if len(errs) > 0 {
+					return cli.NewExitError(fmt.Sprintf("%v problem(s) found in the manifest.", len(errs)), 1)
+				}
+				log.Print("The manifest is valid.")
+				return nil
+			},
+		},
+		{
+			Name:    "list",
+			Aliases: []string{"l"},
+			Usage:   "List the services described by the manifests of the workspace or of the organization.",
+			Flags: append([]cli.Flag{
+				cli.StringFlag{Name: manifestType, Usage: "Only the manifests of this type, e.g. service."},
+				cli.StringFlag{Name: language, Usage: "Only the manifests using this language, e.g. go."},
+				cli.StringFlag{Name: platform, Usage: "Only the manifests running on this platform."},
+				cli.BoolFlag{Name: active, Usage: "Only the active services."},
+				cli.BoolFlag{Name: inactive, Usage: "Only the inactive services."},
+				cli.StringFlag{Name: format, Value: "table", Usage: "Output format: " + strings.Join(core.ManifestFormats, ", ")},
+			}, manifestSourceFlags...),
+			Action: func(c *cli.Context) error {
+				manifests, err := loadManifests(c, cfg)
+				if err != nil {
+					return err
+				}
+				manifests = manifests.Filter(core.ManifestFilter{
+					Type:         c.String(manifestType),
+					Language:     c.String(language),
+					Platform:     c.String(platform),
+					OnlyActive:   c.Bool(active),
+					OnlyInactive: c.Bool(inactive),
+				})
+				return core.WriteManifests(os.Stdout, manifests, c.String(format))
+			},
+		},
+		{
+			Name:    "graph",
+			Aliases: []string{"g"},
+			Usage:   "Export the dependency graph of the services, e.g. 'bub manifest graph | dot -Tsvg > graph.svg'.",
+			Flags: append([]cli.Flag{
+				cli.StringFlag{Name: format, Value: "dot", Usage: "Output format: " + strings.Join(core.GraphFormats, ", ")},
+				cli.StringFlag{Name: focus, Usage: "Only the service, its dependencies and its dependents."},
+			}, manifestSourceFlags...),
+			Action: func(c *cli.Context) error {
+				manifests, err := loadManifests(c, cfg)
+				if err != nil {
+					return err
+				}
+				graph := core.BuildGraph(manifests)
+				if c.String(focus) != "" {
+					if graph, err = graph.Focus(c.String(focus)); err != nil {
+						return cli.NewExitError(err.Error(), 1)
+					}
+				}
+				return core.WriteGraph(os.Stdout, graph, c.String(format))
+			},
+			Subcommands: []cli.Command{
+				{
+					Name:      "dependents",
+					Usage:     "List the services depending directly or transitively on a service or dependency, i.e. what breaks if it goes down.",
+					ArgsUsage: "NAME",
+					Flags:     manifestSourceFlags,
+					Action: func(c *cli.Context) error {
+						if c.NArg() != 1 {
+							return cli.NewExitError("Expected the name of the service or dependency, e.g. postgres", 1)
+						}
+						manifests, err := loadManifests(c, cfg)
+						if err != nil {
+							return err
+						}
+						dependents, err := core.BuildGraph(manifests).Dependents(c.Args().First())
+						if err != nil {
+							return cli.NewExitError(err.Error(), 1)
+						}
+						table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+						fmt.Fprintln(table, "Dependent\tImplicit\tPath")
+						for _, d := range dependents {
+							fmt.Fprintf(table, "%v\t%v\t%v\n", d.Name, d.Implicit, strings.Join(d.Path, " -> "))
+						}
+						return table.Flush()
+					},
+				},
+			},
+		},
+		{
+			Name:  "export",
+			Usage: "Export the manifest to another format, e.g. the Backstage catalog-info.yaml. See docs/backstage.md.",
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: format, Value: "backstage", Usage: "Output format: backstage"},
+				cli.StringFlag{Name: output, Usage: "Write to the file instead of the standard output, e.g. catalog-info.yaml."},
+				cli.StringFlag{Name: owner, Usage: "Owner of the entities, e.g. group:payments. Defaults to the CODEOWNERS of the manifest."},
+			},
+			Action: func(c *cli.Context) error {
+				if c.String(format) != "backstage" {
+					return cli.NewExitError(fmt.Sprintf("unknown format '%v', must be: backstage", c.String(format)), 1)
+				}
+				manifest, err := core.LoadManifest(cfg)
+				if err != nil {
+					return err
+				}
+				if c.String(owner) == "" {
+					if err = github.PopulateOwners(cfg, manifest); err != nil {
+						log.Printf("Could not read the CODEOWNERS: %v", err)
+					}
+				}
+				entities := core.ExportBackstage(*manifest, cfg, c.String(owner))
+				if c.String(output) == "" {
+					return core.WriteBackstage(os.Stdout, entities)
+				}
+				file, err := os.Create(c.String(output))
+				if err != nil {
+					return err
+				}
+				defer file.Close()
+				return core.WriteBackstage(file, entities)
+			},
+		},
+		{
+			Name:      "import",
+			Usage:     "Create the manifest from a Backstage catalog-info.yaml. See docs/backstage.md.",
+			ArgsUsage: "[catalog-info.yaml]",
+			Flags: []cli.Flag{
+				cli.BoolFlag{Name: force, Usage: "Overwrite the existing manifest."},
+			},
+			Action: func(c *cli.Context) error {
+				catalogFile := "catalog-info.yaml"
+				if c.NArg() > 0 {
+					catalogFile = c.Args().First()
+				}
+				data, err := ioutil.ReadFile(catalogFile)
+				if err != nil {
+					return err
+				}
+				manifest, err := core.ImportBackstage(data)
+				if err != nil {
+					return cli.NewExitError(fmt.Sprintf("%v: %v", catalogFile, err), 1)
+				}
+				manifestPath := path.Join(path.Dir(catalogFile), ".bench.yml")
+				if exists, _ := utils.PathExists(manifestPath); exists && !c.Bool(force) {
+					return cli.NewExitError(manifestPath+" already exists, use --force to overwrite it.", 1)
+				}
+				if err = core.WriteManifest(manifestPath, *manifest); err != nil {
+					return err
+				}
+				log.Printf("Created %v, run 'bub manifest validate' to check it.", manifestPath)
+				return nil
+			},
+		},
+		{
+			Name:  "drift",
+			Usage: "Compare the languages, dependencies and protocols of the manifest with the repository, exits with a non-zero code on drift.",
+			Flags: []cli.Flag{
+				cli.BoolFlag{Name: fix, Usage: "Rewrite the manifest with the suggested fixes."},
+			},
+			Action: func(c *cli.Context) error {
+				root, err := core.InitGit().GetRepositoryRootPath()
+				if err != nil {
+					return cli.NewExitError("Must be executed in a repository.", 1)
+				}
+				dir, err := core.FindManifestDir(root, ".")
+				if err != nil {
+					return err
+				}
+				drifts, err := core.CheckManifestDrift(dir)
+				if err != nil {
+					return cli.NewExitError(err.Error(), 1)
+				}
+				if len(drifts) == 0 {
+					log.Print("The manifest matches the repository.")
+					return nil
+				}
+				for _, d := range drifts {
+					fmt.Printf("%v\n  fix: %v\n", d.Message, d.Fix)
+				}
+				if !c.Bool(fix) {
+					return cli.NewExitError(fmt.Sprintf("%v difference(s) found, run 'bub manifest drift --fix' to update the manifest.", len(drifts)), 1)
+				}
+				if err = core.FixManifestDrift(dir, drifts); err != nil {
+					return err
+				}
+				log.Print("The manifest was updated, review the changes before committing them.")
+				return nil
+			},
+		},
+		{
+			Name:  "owners",
+			Usage: "Report the ownership of the tracked files from the CODEOWNERS rules, e.g. the unowned files.",
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: format, Value: "table", Usage: "Output format: table, json"},
+			},
+			Action: func(c *cli.Context) error {
+				report, err := github.ReadOwnershipReport(cfg)
+				if err != nil {
+					return cli.NewExitError(err.Error(), 1)
+				}
+				return github.WriteOwnershipReport(os.Stdout, report, c.String(format))
+			},
+		},
+		{
+			Name:      "api-diff",
+			Usage:     "Compare the API definitions of the protocols with the base branch, exits with a non-zero code on breaking changes.",
+			ArgsUsage: "[base]",
+			Action: func(c *cli.Context) error {
+				m, err := core.LoadManifest(cfg)
+				if err != nil {
+					return cli.NewExitError(err.Error(), 1)
+				}
+				if len(m.Protocols) == 0 {
+					log.Print("The manifest does not declare any protocol.")
+					return nil
+				}
+				root, err := core.InitGit().GetRepositoryRootPath()
+				if err != nil {
+					return err
+				}
+				g := core.MustInitGit(root).WithConfig(cfg)
+				base := c.Args().First()
+				if base == "" {
+					base = g.BaseBranch()
+				}
+				changes, err := core.DiffProtocols(g, m, base)
+				if err != nil {
+					return cli.NewExitError(err.Error(), 1)
+				}
+				if len(changes) == 0 {
+					log.Printf("No API change since '%v'.", base)
+					return nil
+				}
+				w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+				for _, change := range changes {
+					kind := "non-breaking"
+					if change.Breaking {
+						kind = "BREAKING"
+					}
+					fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", kind, change.File, change.Location, change.Message)
+				}
+				w.Flush()
+				if count := changes.CountBreaking(); count > 0 {
+					return cli.NewExitError(fmt.Sprintf("%v breaking change(s) since '%v'.", count, base), 1)
 				}
-				yml, _ := yaml.Marshal(manifest)
-				log.Println(string(yml))
 				return nil
 			},
 		},
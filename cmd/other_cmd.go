@@ -2,38 +2,9 @@ package cmd
 
 import (
 	"github.com/j-martin/bub/core"
-	"github.com/j-martin/bub/integrations/atlassian"
-	"github.com/j-martin/bub/integrations/ci"
-	"github.com/j-martin/bub/integrations/github"
-	"github.com/j-martin/bub/integrations/vault"
 	"github.com/urfave/cli"
-	"log"
 )
 
-func buildSetupCmd() cli.Command {
-	resetCredentials := "reset-credentials"
-	return cli.Command{
-		Name:  "setup",
-		Usage: "Setup bub on your machine.",
-		Flags: []cli.Flag{
-			cli.BoolFlag{Name: resetCredentials, Usage: "Prompt you to re-enter credentials."},
-		},
-		Action: func(c *cli.Context) error {
-			core.MustSetupConfig()
-			// Reloading the config
-			cfg, _ := core.LoadConfiguration()
-			cfg.ResetCredentials = c.Bool(resetCredentials)
-			atlassian.MustSetupJIRA(cfg)
-			atlassian.MustSetupConfluence(cfg)
-			github.MustSetupGitHub(cfg)
-			ci.MustSetupJenkins(cfg)
-			vault.MustSetupVault(cfg)
-			log.Println("Done.")
-			return nil
-		},
-	}
-}
-
 func buildRepositoryCmds(cfg *core.Configuration, manifest *core.Manifest) []cli.Command {
 	slackFormat := "slack-format"
 	noSlackAt := "slack-no-at"
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/j-martin/bub/core"
+	"github.com/j-martin/bub/integrations/atlassian"
+	"github.com/j-martin/bub/integrations/ci"
+	"github.com/j-martin/bub/integrations/github"
+	"github.com/j-martin/bub/integrations/vault"
+	"github.com/j-martin/bub/utils"
+	"github.com/urfave/cli"
+	"log"
+	"strings"
+)
+
+var setupIntegrations = map[string]func(cfg *core.Configuration){
+	"jira":       atlassian.MustSetupJIRA,
+	"confluence": atlassian.MustSetupConfluence,
+	"github":     github.MustSetupGitHub,
+	"jenkins":    ci.MustSetupJenkins,
+	"vault":      vault.MustSetupVault,
+}
+
+func buildSetupCmd() cli.Command {
+	resetCredentials := "reset-credentials"
+	nonInteractive := "non-interactive"
+	enable := "enable"
+	answersFile := "answers"
+	return cli.Command{
+		Name:  "setup",
+		Usage: "Setup bub on your machine.",
+		Description: "Asks which integrations to enable and sets up their credentials.\n\n" +
+			"   With --non-interactive, nothing is prompted. The enabled integrations, the config values and the credentials\n" +
+			"   are read from the answers file, e.g.:\n\n" +
+			"     enable: [jira, github]\n" +
+			"     config:\n" +
+			"       jira.server: https://example.atlassian.net\n" +
+			"     credentials:\n" +
+			"       GitHub Token: <token>\n\n" +
+			"   Credentials missing from the file are read from the environment, e.g. GITHUB_TOKEN or JIRA_PASSWORD.",
+		Flags: []cli.Flag{
+			cli.BoolFlag{Name: resetCredentials, Usage: "Prompt you to re-enter credentials."},
+			cli.BoolFlag{Name: nonInteractive, Usage: "Do not prompt, use the flags, the answers file and the environment.", EnvVar: "BUB_SETUP_NON_INTERACTIVE"},
+			cli.StringFlag{Name: enable, Usage: "Integrations to enable, e.g. jira,github. The others are disabled. Without it, the config is left as is. One of: " + strings.Join(core.Integrations, ", "), EnvVar: "BUB_SETUP_ENABLE"},
+			cli.StringFlag{Name: answersFile, Usage: "YAML file containing the setup answers.", EnvVar: "BUB_SETUP_ANSWERS"},
+		},
+		Action: func(c *cli.Context) error {
+			answers, err := core.LoadSetupAnswers(c.String(answersFile))
+			if err != nil {
+				return err
+			}
+			enabled, hasEnabled := answers.Enable, len(answers.Enable) > 0
+			if c.String(enable) != "" {
+				enabled, hasEnabled = utils.SplitAndTrim(c.String(enable), ","), true
+				if err = core.CheckIntegrations(enabled); err != nil {
+					return err
+				}
+			}
+
+			if c.Bool(nonInteractive) {
+				if err = core.CreateConfiguration(); err != nil {
+					return err
+				}
+				if len(answers.Config) > 0 {
+					if err = core.SetConfigValues(core.ConfigUserFile, answers.Config); err != nil {
+						return err
+					}
+				}
+			} else {
+				core.MustSetupConfig()
+				if !hasEnabled {
+					enabled, hasEnabled = askForIntegrations(), true
+				}
+			}
+			if hasEnabled {
+				if err = core.SetIntegrations(enabled); err != nil {
+					return err
+				}
+			} else {
+				log.Print("No integration to enable given, keeping the integrations enabled in the config.")
+			}
+
+			// Reloading the config
+			cfg, err := core.LoadConfiguration(c.GlobalString(profileFlag))
+			if err != nil {
+				return err
+			}
+			cfg.ResetCredentials = c.Bool(resetCredentials)
+			for _, integration := range core.Integrations {
+				if !cfg.IsIntegrationEnabled(integration) {
+					log.Printf("Skipping %v, it is disabled.", integration)
+					continue
+				}
+				if !c.Bool(nonInteractive) {
+					setupIntegrations[integration](cfg)
+					continue
+				}
+				if err = cfg.StoreSetupCredentials(integration, answers); err != nil {
+					return cli.NewExitError(err.Error(), 1)
+				}
+			}
+			log.Println("Done.")
+			return nil
+		},
+	}
+}
+
+func askForIntegrations() (enabled []string) {
+	for _, integration := range core.Integrations {
+		if utils.AskForConfirmation(fmt.Sprintf("Enable %v?", integration)) {
+			enabled = append(enabled, integration)
+		}
+	}
+	return enabled
+}
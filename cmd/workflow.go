@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"github.com/j-martin/bub/core"
 	"github.com/j-martin/bub/integrations/atlassian"
@@ -20,7 +21,7 @@ type Workflow struct {
 func MustInitWorkflow(cfg *core.Configuration, manifest *core.Manifest) *Workflow {
 	return &Workflow{
 		cfg:      cfg,
-		git:      core.InitGit(),
+		git:      core.InitGit().WithConfig(cfg),
 		github:   github.MustInitGitHub(cfg),
 		jira:     atlassian.MustInitJIRA(cfg),
 		manifest: manifest,
@@ -29,7 +30,7 @@ func MustInitWorkflow(cfg *core.Configuration, manifest *core.Manifest) *Workflo
 
 func (wf *Workflow) Git() *core.Git {
 	if wf.git == nil {
-		wf.git = core.InitGit()
+		wf.git = core.InitGit().WithConfig(wf.cfg)
 	}
 	return wf.git
 }
@@ -48,20 +49,20 @@ func (wf *Workflow) JIRA() *atlassian.JIRA {
 	return wf.jira
 }
 
-func (wf *Workflow) MassUpdate(unstash bool) error {
-	return core.ForEachRepo(func(repoDir string) (string, error) {
-		return core.MustInitGit(repoDir).Sync(unstash)
+func (wf *Workflow) MassUpdate(filter core.WorkspaceFilter, opts core.ConcurrencyOptions, unstash bool) error {
+	return core.ForEachRepo(filter, opts, func(ctx context.Context, repoDir string) (string, error) {
+		return core.MustInitGit(repoDir).WithContext(ctx).WithConfig(wf.cfg).Sync(unstash)
 	})
 }
 
-func (wf *Workflow) MassStart(unstash bool) error {
+func (wf *Workflow) MassStart(filter core.WorkspaceFilter, opts core.ConcurrencyOptions, unstash bool) error {
 	issue, err := wf.JIRA().PickAssignedIssue()
 	if err != nil {
 		return err
 	}
 
-	return core.ForEachRepo(func(repo string) (string, error) {
-		g := core.MustInitGit(repo)
+	return core.ForEachRepo(filter, opts, func(ctx context.Context, repo string) (string, error) {
+		g := core.MustInitGit(repo).WithContext(ctx).WithConfig(wf.cfg)
 		output, err := g.Sync(unstash)
 		if err != nil {
 			return output, err
@@ -70,23 +71,23 @@ func (wf *Workflow) MassStart(unstash bool) error {
 	})
 }
 
-func (wf *Workflow) MassDiff() error {
-	return core.ForEachRepo(func(repo string) (string, error) {
-		g := core.MustInitGit(repo)
+func (wf *Workflow) MassDiff(filter core.WorkspaceFilter, opts core.ConcurrencyOptions) error {
+	return core.ForEachRepo(filter, opts, func(ctx context.Context, repo string) (string, error) {
+		g := core.MustInitGit(repo).WithContext(ctx).WithConfig(wf.cfg)
 		return g.Diff()
 	})
 }
 
-func (wf *Workflow) MassDone(noOperation bool) error {
-	return core.ForEachRepo(func(repoDir string) (string, error) {
-		g := core.MustInitGit(repoDir)
+func (wf *Workflow) MassDone(filter core.WorkspaceFilter, opts core.ConcurrencyOptions, noOperation bool) error {
+	return core.ForEachRepo(filter, opts, func(ctx context.Context, repoDir string) (string, error) {
+		g := core.MustInitGit(repoDir).WithContext(ctx).WithConfig(wf.cfg)
 		if g.ContainedUncommittedChanges() {
 			utils.ConditionalOp(fmt.Sprintf("%v - Committing.", repoDir), noOperation, func() error {
 				return g.CommitWithBranchName()
 			})
 		}
 
-		if !g.IsDifferentFromMaster() {
+		if !g.IsDifferentFromBase() {
 			log.Printf("%v - No commits. Skipping.", repoDir)
 			return "", nil
 		}
@@ -128,8 +129,8 @@ func (wf *Workflow) OpenCommit(c *core.GitCommit) error {
 		"GitHub Commit": func() error {
 			return wf.GitHub().OpenCommit(wf.manifest, c)
 		},
-		"GitHub Compare with Master": func() error {
-			return wf.GitHub().OpenCompareCommitsPage(wf.manifest, c, "master")
+		"GitHub Compare with the Base Branch": func() error {
+			return wf.GitHub().OpenCompareCommitsPage(wf.manifest, c, wf.Git().BaseBranch())
 		},
 	}
 	if len(pr) > 2 && pr[2] != "" {
@@ -92,50 +92,51 @@ func buildWorkflowCmds(cfg *core.Configuration, manifest *core.Manifest) []cli.C
 			Subcommands: []cli.Command{
 				{
 					Name:  "start",
-					Usage: "Clean the repository, checkout master, pull and create new branch.",
-					Flags: []cli.Flag{
+					Usage: "Clean the repository, checkout the base branch, pull and create new branch.",
+					Flags: append([]cli.Flag{
 						cli.BoolFlag{Name: unstash, Usage: unstashDesc},
-					},
+					}, workspaceFlags...),
 					Action: func(c *cli.Context) error {
 						if !utils.AskForConfirmation("You will lose existing changes.") {
 							os.Exit(1)
 						}
-						return MustInitWorkflow(cfg, manifest).MassStart(c.Bool(unstash))
+						return MustInitWorkflow(cfg, manifest).MassStart(workspaceFilter(c), concurrencyOptions(c), c.Bool(unstash))
 					},
 				},
 				{
 					Name:    "diff",
 					Aliases: []string{"d"},
 					Usage:   "Shows the diff of all repos.",
+					Flags:   workspaceFlags,
 					Action: func(c *cli.Context) error {
-						return MustInitWorkflow(cfg, manifest).MassDiff()
+						return MustInitWorkflow(cfg, manifest).MassDiff(workspaceFilter(c), concurrencyOptions(c))
 					},
 				},
 				{
 					Name:  "done",
 					Usage: "Commit changes and create PRs. To be used after running '... start' and you made your changes.",
-					Flags: []cli.Flag{
+					Flags: append([]cli.Flag{
 						cli.BoolFlag{Name: noOperation, Usage: "Do not do any actions."},
-					},
+					}, workspaceFlags...),
 					Action: func(c *cli.Context) error {
 						if !utils.AskForConfirmation("You will create a PR for every changes made to the repo. Use `--noop` to check first. Continue?") {
 							os.Exit(1)
 						}
-						return MustInitWorkflow(cfg, manifest).MassDone(c.Bool(noOperation))
+						return MustInitWorkflow(cfg, manifest).MassDone(workspaceFilter(c), concurrencyOptions(c), c.Bool(noOperation))
 					},
 				},
 				{
 					Name:    "update",
 					Aliases: []string{"u"},
-					Usage:   "Clean the repository, checkout master and pull.",
-					Flags: []cli.Flag{
+					Usage:   "Clean the repository, checkout the base branch and pull.",
+					Flags: append([]cli.Flag{
 						cli.BoolFlag{Name: unstash, Usage: unstashDesc},
-					},
+					}, workspaceFlags...),
 					Action: func(c *cli.Context) error {
 						if !utils.AskForConfirmation("You will lose existing changes.") {
 							os.Exit(1)
 						}
-						return MustInitWorkflow(cfg, manifest).MassUpdate(c.Bool(unstash))
+						return MustInitWorkflow(cfg, manifest).MassUpdate(workspaceFilter(c), concurrencyOptions(c), c.Bool(unstash))
 					},
 				},
 			},
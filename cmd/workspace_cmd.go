@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/j-martin/bub/core"
+	"github.com/j-martin/bub/integrations/github"
+	"github.com/j-martin/bub/utils"
+	"github.com/urfave/cli"
+)
+
+const (
+	jobsFlag    = "jobs"
+	timeoutFlag = "timeout"
+)
+
+// concurrencyFlags are the flags used by concurrencyOptions.
+var concurrencyFlags = []cli.Flag{
+	cli.IntFlag{Name: jobsFlag, Value: 8, Usage: "Number of repositories processed at once.", EnvVar: "BUB_JOBS"},
+	cli.DurationFlag{Name: timeoutFlag, Value: 10 * time.Minute, Usage: "Timeout of each repository, e.g. 90s, 0 for none."},
+}
+
+func concurrencyOptions(c *cli.Context) core.ConcurrencyOptions {
+	return core.ConcurrencyOptions{Jobs: c.Int(jobsFlag), Timeout: c.Duration(timeoutFlag)}
+}
+
+const (
+	groupFlag    = "group"
+	onlyFlag     = "only"
+	excludeFlag  = "exclude"
+	typeFlag     = "type"
+	languageFlag = "language"
+)
+
+// workspaceFilterFlags are the flags used by workspaceFilter.
+var workspaceFilterFlags = []cli.Flag{
+	cli.StringSliceFlag{Name: groupFlag, Usage: "Only the repositories of the group of the " + core.WorkspaceFile + ", can be repeated."},
+	cli.StringSliceFlag{Name: onlyFlag, Usage: "Only these repositories, e.g. billing,ledger."},
+	cli.StringSliceFlag{Name: excludeFlag, Usage: "Exclude these repositories, e.g. legacy-api."},
+	cli.StringFlag{Name: typeFlag, Usage: "Only the repositories with a manifest of this type, e.g. service."},
+	cli.StringFlag{Name: languageFlag, Usage: "Only the repositories with a manifest using this language, e.g. go."},
+}
+
+// workspaceFlags are the flags of the commands applied to the repositories of the workspace.
+var workspaceFlags = append(append([]cli.Flag{}, workspaceFilterFlags...), concurrencyFlags...)
+
+func workspaceFilter(c *cli.Context) core.WorkspaceFilter {
+	return core.WorkspaceFilter{
+		Groups:  splitValues(c.StringSlice(groupFlag)),
+		Only:    splitValues(c.StringSlice(onlyFlag)),
+		Exclude: splitValues(c.StringSlice(excludeFlag)),
+		Manifest: core.ManifestFilter{
+			Type:     c.String(typeFlag),
+			Language: c.String(languageFlag),
+		},
+	}
+}
+
+// splitValues splits the comma separated values of the repeated flag.
+func splitValues(values []string) (split []string) {
+	for _, v := range values {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				split = append(split, s)
+			}
+		}
+	}
+	return split
+}
+
+func buildWorkspaceCmds(cfg *core.Configuration) []cli.Command {
+	dir := "dir"
+	topic := "topic"
+	manifestType := "type"
+	protocol := "protocol"
+	return []cli.Command{
+		{
+			Name:    "list",
+			Aliases: []string{"l"},
+			Usage:   "Lists the repositories of the workspace matching the filters, as used by 'workflow mass'.",
+			Flags:   workspaceFilterFlags,
+			Action: func(c *cli.Context) error {
+				w, err := core.LoadWorkspace(".")
+				if err != nil {
+					return err
+				}
+				repos, err := w.SelectRepositories(workspaceFilter(c))
+				if err != nil {
+					return err
+				}
+				for _, r := range repos {
+					fmt.Println(r)
+				}
+				return nil
+			},
+		},
+		{
+			Name:    "clone",
+			Aliases: []string{"c"},
+			Usage:   "Clones the missing repositories of the organization, forks and archived excluded, and syncs the existing ones.",
+			Flags: append([]cli.Flag{
+				cli.StringFlag{Name: dir, Value: ".", Usage: "Directory containing the repositories."},
+				cli.StringSliceFlag{Name: topic, Usage: "Only the repositories with the GitHub topic, can be repeated."},
+				cli.StringFlag{Name: manifestType, Usage: "Only the repositories whose manifest is of the type, e.g. service."},
+				cli.StringFlag{Name: protocol, Usage: "Clone over ssh or https, github.cloneProtocol of the config by default."},
+			}, concurrencyFlags...),
+			Action: func(c *cli.Context) error {
+				if c.String(protocol) != "" {
+					if !utils.Contains(c.String(protocol), "ssh", "https") {
+						return cli.NewExitError(fmt.Sprintf("The protocol must be ssh or https, got '%v'.", c.String(protocol)), 1)
+					}
+					cfg.GitHub.CloneProtocol = c.String(protocol)
+				}
+				names, err := github.MustInitGitHub(cfg).ListRepositoryNames(github.RepositoryFilter{
+					Topics:       c.StringSlice(topic),
+					ManifestType: c.String(manifestType),
+				})
+				if err != nil {
+					return err
+				}
+				if len(names) == 0 {
+					log.Print("No repository matches the filters.")
+					return nil
+				}
+				var repos []string
+				for _, name := range names {
+					repos = append(repos, path.Join(c.String(dir), name))
+				}
+				return core.ConcurrentRepositoryOperations(repos, concurrencyOptions(c), func(ctx context.Context, repoDir string) (string, error) {
+					return core.MustInitGit(repoDir).WithContext(ctx).WithConfig(cfg).CloneOrSync(cfg.CloneURL(path.Base(repoDir)))
+				})
+			},
+		},
+	}
+}
@@ -0,0 +1,679 @@
+package core
+
+import (
+	"fmt"
+	"github.com/j-martin/bub/utils"
+	"gopkg.in/yaml.v3"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// APIChange is a difference between two versions of an API definition.
+type APIChange struct {
+	Breaking bool
+	File     string
+	// Location is the endpoint, schema or message changed, e.g. 'GET /pets/{id} response 200.name'.
+	Location string
+	Message  string
+}
+
+type APIChanges []APIChange
+
+// CountBreaking returns the number of breaking changes.
+func (changes APIChanges) CountBreaking() (count int) {
+	for _, c := range changes {
+		if c.Breaking {
+			count++
+		}
+	}
+	return count
+}
+
+// apiFileExtensions are the files compared for each protocol type, the other types are skipped.
+var apiFileExtensions = map[string][]string{
+	"openapi": {".yaml", ".yml", ".json"},
+	"raml":    {".raml"},
+	"proto":   {".proto"},
+}
+
+var httpMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// apiDefinition is the common representation of the OpenAPI and RAML definitions.
+type apiDefinition struct {
+	// Operations by method and path, e.g. 'GET /pets/{id}'.
+	Operations map[string]apiOperation
+	// Schemas are the named types, e.g. the components.schemas of OpenAPI or the types of RAML.
+	Schemas schema
+}
+
+type apiOperation struct {
+	// Parameters by location and name, e.g. 'query limit'.
+	Parameters map[string]apiParameter
+	Request    schema
+	// Responses are the schemas of the successful responses by status code, nil if there is no body.
+	Responses map[string]schema
+}
+
+type apiParameter struct {
+	Required bool
+	Type     string
+}
+
+// schema is a JSON schema as decoded from the definition.
+type schema map[string]interface{}
+
+type schemaUsage int
+
+const (
+	requestSchema schemaUsage = iota
+	responseSchema
+	// sharedSchema is used by the requests and the responses, e.g. the components.schemas.
+	sharedSchema
+)
+
+// DiffProtocols compares the definitions of the protocols of the manifest between the fork point of
+// HEAD from the base ref and HEAD, the changes made on the base since then are not reported.
+func DiffProtocols(g *Git, m *Manifest, base string) (changes APIChanges, err error) {
+	forkPoint, err := g.MergeBase(base)
+	if err != nil {
+		return nil, fmt.Errorf("could not find the fork point of HEAD from '%v': %v", base, err)
+	}
+	for _, p := range m.Protocols {
+		extensions, ok := apiFileExtensions[p.Type]
+		if !ok {
+			log.Printf("Skipping the '%v' protocol, only openapi, raml and proto are supported.", p.Type)
+			continue
+		}
+		protocolPath := path.Join(m.Dir, p.Path)
+		baseFiles, err := listAPIFiles(g, forkPoint, protocolPath, extensions)
+		if err != nil {
+			return nil, err
+		}
+		headFiles, err := listAPIFiles(g, "HEAD", protocolPath, extensions)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range baseFiles {
+			if !utils.Contains(f, headFiles...) {
+				changes = append(changes, APIChange{Breaking: true, File: f, Message: "definition removed"})
+				continue
+			}
+			baseData, err := g.ShowFileAt(forkPoint, f)
+			if err != nil {
+				return nil, err
+			}
+			headData, err := g.ShowFileAt("HEAD", f)
+			if err != nil {
+				return nil, err
+			}
+			fileChanges, err := DiffAPI(p.Type, baseData, headData)
+			if err != nil {
+				return nil, fmt.Errorf("%v: %v", f, err)
+			}
+			for _, c := range fileChanges {
+				c.File = f
+				changes = append(changes, c)
+			}
+		}
+		for _, f := range headFiles {
+			if !utils.Contains(f, baseFiles...) {
+				changes = append(changes, APIChange{File: f, Message: "definition added"})
+			}
+		}
+	}
+	return changes, nil
+}
+
+func listAPIFiles(g *Git, ref, protocolPath string, extensions []string) (files []string, err error) {
+	all, err := g.ListFilesAt(ref, protocolPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not list the files of '%v' at '%v': %v", protocolPath, ref, err)
+	}
+	for _, f := range all {
+		if utils.Contains(path.Ext(f), extensions...) {
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
+
+// DiffAPI compares two versions of a definition of the protocol type, e.g. openapi.
+func DiffAPI(protocolType string, base, head []byte) (changes APIChanges, err error) {
+	if protocolType == "proto" {
+		changes = diffProto(parseProto(base), parseProto(head))
+	} else {
+		parse := parseOpenAPI
+		if protocolType == "raml" {
+			parse = parseRAML
+		}
+		baseDef, err := parse(base)
+		if err != nil {
+			return nil, err
+		}
+		headDef, err := parse(head)
+		if err != nil {
+			return nil, err
+		}
+		changes = diffDefinitions(baseDef, headDef)
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Location != changes[j].Location {
+			return changes[i].Location < changes[j].Location
+		}
+		return changes[i].Message < changes[j].Message
+	})
+	return changes, nil
+}
+
+func diffDefinitions(base, head *apiDefinition) (changes APIChanges) {
+	for key, b := range base.Operations {
+		h, ok := head.Operations[key]
+		if !ok {
+			changes = append(changes, APIChange{Breaking: true, Location: key, Message: "endpoint removed"})
+			continue
+		}
+		changes = append(changes, diffOperation(key, b, h)...)
+	}
+	for key := range head.Operations {
+		if _, ok := base.Operations[key]; !ok {
+			changes = append(changes, APIChange{Location: key, Message: "endpoint added"})
+		}
+	}
+	for name, b := range base.Schemas {
+		h, ok := head.Schemas[name]
+		if !ok {
+			changes = append(changes, APIChange{Breaking: true, Location: name, Message: "schema removed"})
+			continue
+		}
+		changes = append(changes, diffSchema(name, schemaMap(b), schemaMap(h), sharedSchema)...)
+	}
+	for name := range head.Schemas {
+		if _, ok := base.Schemas[name]; !ok {
+			changes = append(changes, APIChange{Location: name, Message: "schema added"})
+		}
+	}
+	return changes
+}
+
+func diffOperation(location string, base, head apiOperation) (changes APIChanges) {
+	for key, b := range base.Parameters {
+		h, ok := head.Parameters[key]
+		if !ok {
+			changes = append(changes, APIChange{Location: location, Message: fmt.Sprintf("parameter '%v' removed", key)})
+			continue
+		}
+		if h.Required && !b.Required {
+			changes = append(changes, APIChange{Breaking: true, Location: location, Message: fmt.Sprintf("parameter '%v' is now required", key)})
+		}
+		if b.Type != h.Type {
+			changes = append(changes, APIChange{Breaking: true, Location: location, Message: fmt.Sprintf("parameter '%v' type changed from '%v' to '%v'", key, b.Type, h.Type)})
+		}
+	}
+	for key, h := range head.Parameters {
+		if _, ok := base.Parameters[key]; ok {
+			continue
+		}
+		if h.Required {
+			changes = append(changes, APIChange{Breaking: true, Location: location, Message: fmt.Sprintf("required parameter '%v' added", key)})
+		} else {
+			changes = append(changes, APIChange{Location: location, Message: fmt.Sprintf("optional parameter '%v' added", key)})
+		}
+	}
+
+	switch {
+	case base.Request == nil && head.Request != nil:
+		changes = append(changes, APIChange{Breaking: true, Location: location, Message: "request body added"})
+	case base.Request != nil && head.Request == nil:
+		changes = append(changes, APIChange{Location: location, Message: "request body removed"})
+	default:
+		changes = append(changes, diffSchema(location+" request", base.Request, head.Request, requestSchema)...)
+	}
+
+	for code, b := range base.Responses {
+		h, ok := head.Responses[code]
+		responseLocation := location + " response " + code
+		switch {
+		case !ok:
+			changes = append(changes, APIChange{Breaking: true, Location: location, Message: fmt.Sprintf("response %v removed", code)})
+		case b != nil && h == nil:
+			changes = append(changes, APIChange{Breaking: true, Location: responseLocation, Message: "body removed"})
+		case b != nil:
+			changes = append(changes, diffSchema(responseLocation, b, h, responseSchema)...)
+		}
+	}
+	for code := range head.Responses {
+		if _, ok := base.Responses[code]; !ok {
+			changes = append(changes, APIChange{Location: location, Message: fmt.Sprintf("response %v added", code)})
+		}
+	}
+	return changes
+}
+
+// diffSchema compares the schemas. What breaks depends on the usage, e.g. removing a property
+// breaks the clients reading a response but not the ones sending a request.
+func diffSchema(location string, base, head schema, usage schemaUsage) (changes APIChanges) {
+	for _, key := range []string{"$ref", "type", "format"} {
+		if b, h := schemaString(base, key), schemaString(head, key); b != h {
+			changes = append(changes, APIChange{Breaking: true, Location: location, Message: fmt.Sprintf("%v changed from '%v' to '%v'", key, b, h)})
+		}
+	}
+
+	baseProperties, headProperties := schemaMap(base["properties"]), schemaMap(head["properties"])
+	baseRequired, headRequired := schemaValues(base, "required"), schemaValues(head, "required")
+	for name, b := range baseProperties {
+		propertyLocation := location + "." + name
+		h, ok := headProperties[name]
+		if !ok {
+			changes = append(changes, APIChange{Breaking: usage != requestSchema, Location: propertyLocation, Message: "property removed"})
+			continue
+		}
+		if headRequired[name] && !baseRequired[name] {
+			changes = append(changes, APIChange{Breaking: usage != responseSchema, Location: propertyLocation, Message: "property is now required"})
+		}
+		if baseRequired[name] && !headRequired[name] {
+			changes = append(changes, APIChange{Breaking: usage != requestSchema, Location: propertyLocation, Message: "property is no longer required"})
+		}
+		changes = append(changes, diffSchema(propertyLocation, schemaMap(b), schemaMap(h), usage)...)
+	}
+	for name := range headProperties {
+		if _, ok := baseProperties[name]; ok {
+			continue
+		}
+		if headRequired[name] {
+			changes = append(changes, APIChange{Breaking: usage != responseSchema, Location: location + "." + name, Message: "required property added"})
+		} else {
+			changes = append(changes, APIChange{Location: location + "." + name, Message: "optional property added"})
+		}
+	}
+
+	baseEnum, headEnum := schemaValues(base, "enum"), schemaValues(head, "enum")
+	if len(baseEnum) > 0 || len(headEnum) > 0 {
+		for value := range baseEnum {
+			if !headEnum[value] {
+				changes = append(changes, APIChange{Breaking: usage != responseSchema, Location: location, Message: fmt.Sprintf("enum value '%v' removed", value)})
+			}
+		}
+		for value := range headEnum {
+			if !baseEnum[value] {
+				changes = append(changes, APIChange{Breaking: usage != requestSchema, Location: location, Message: fmt.Sprintf("enum value '%v' added", value)})
+			}
+		}
+	}
+
+	if base["items"] != nil && head["items"] != nil {
+		changes = append(changes, diffSchema(location+"[]", schemaMap(base["items"]), schemaMap(head["items"]), usage)...)
+	}
+	return changes
+}
+
+func schemaMap(v interface{}) schema {
+	if m, ok := v.(map[string]interface{}); ok {
+		return m
+	}
+	return nil
+}
+
+func schemaString(s schema, key string) string {
+	if v, ok := s[key]; ok && v != nil {
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+// schemaValues returns the values of a list, e.g. required or enum.
+func schemaValues(s schema, key string) map[string]bool {
+	values := map[string]bool{}
+	if items, ok := s[key].([]interface{}); ok {
+		for _, i := range items {
+			values[fmt.Sprint(i)] = true
+		}
+	}
+	return values
+}
+
+// decodeAPIDocument decodes the YAML or JSON document, the keys are converted to strings e.g. the status codes.
+func decodeAPIDocument(data []byte) (schema, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	s := schemaMap(stringKeys(doc))
+	if s == nil {
+		s = schema{}
+	}
+	return s, nil
+}
+
+func stringKeys(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(value))
+		for k, i := range value {
+			m[fmt.Sprint(k)] = stringKeys(i)
+		}
+		return m
+	case map[string]interface{}:
+		for k, i := range value {
+			value[k] = stringKeys(i)
+		}
+		return value
+	case []interface{}:
+		for k, i := range value {
+			value[k] = stringKeys(i)
+		}
+		return value
+	}
+	return v
+}
+
+// preferredContent returns the JSON content if any, otherwise the first by content type.
+func preferredContent(content schema) (interface{}, bool) {
+	if c, ok := content["application/json"]; ok {
+		return c, true
+	}
+	var contentTypes []string
+	for contentType := range content {
+		contentTypes = append(contentTypes, contentType)
+	}
+	if len(contentTypes) == 0 {
+		return nil, false
+	}
+	sort.Strings(contentTypes)
+	return content[contentTypes[0]], true
+}
+
+func isSuccessStatus(code string) bool {
+	return strings.HasPrefix(code, "2")
+}
+
+// parseOpenAPI parses an OpenAPI 3 or Swagger 2 definition.
+func parseOpenAPI(data []byte) (*apiDefinition, error) {
+	doc, err := decodeAPIDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	def := &apiDefinition{Operations: map[string]apiOperation{}, Schemas: schema{}}
+	for p, item := range schemaMap(doc["paths"]) {
+		pathItem := schemaMap(item)
+		for _, method := range httpMethods {
+			op, ok := pathItem[method]
+			if !ok {
+				continue
+			}
+			def.Operations[strings.ToUpper(method)+" "+p] = parseOpenAPIOperation(pathItem["parameters"], schemaMap(op))
+		}
+	}
+	for name, s := range schemaMap(schemaMap(doc["components"])["schemas"]) {
+		def.Schemas[name] = s
+	}
+	for name, s := range schemaMap(doc["definitions"]) {
+		def.Schemas[name] = s
+	}
+	return def, nil
+}
+
+func parseOpenAPIOperation(pathParameters interface{}, op schema) apiOperation {
+	operation := apiOperation{Parameters: map[string]apiParameter{}, Responses: map[string]schema{}}
+	parameters, _ := pathParameters.([]interface{})
+	operationParameters, _ := op["parameters"].([]interface{})
+	for _, i := range append(parameters, operationParameters...) {
+		p := schemaMap(i)
+		if ref := schemaString(p, "$ref"); ref != "" {
+			operation.Parameters[ref] = apiParameter{}
+			continue
+		}
+		if p["in"] == "body" {
+			operation.Request = schemaMap(p["schema"])
+			continue
+		}
+		parameterType := schemaString(p, "type")
+		if parameterType == "" {
+			parameterType = schemaString(schemaMap(p["schema"]), "type")
+		}
+		required, _ := p["required"].(bool)
+		operation.Parameters[schemaString(p, "in")+" "+schemaString(p, "name")] = apiParameter{Required: required, Type: parameterType}
+	}
+	if content, ok := preferredContent(schemaMap(schemaMap(op["requestBody"])["content"])); ok {
+		operation.Request = schemaMap(schemaMap(content)["schema"])
+		if operation.Request == nil {
+			operation.Request = schema{}
+		}
+	}
+	for code, r := range schemaMap(op["responses"]) {
+		if !isSuccessStatus(code) {
+			continue
+		}
+		response := schemaMap(r)
+		operation.Responses[code] = schemaMap(response["schema"])
+		if content, ok := preferredContent(schemaMap(response["content"])); ok {
+			operation.Responses[code] = schemaMap(schemaMap(content)["schema"])
+		}
+	}
+	return operation
+}
+
+// parseRAML parses a RAML definition, the types are converted to JSON schemas.
+func parseRAML(data []byte) (*apiDefinition, error) {
+	doc, err := decodeAPIDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	def := &apiDefinition{Operations: map[string]apiOperation{}, Schemas: schema{}}
+	for _, key := range []string{"types", "schemas"} {
+		for name, t := range schemaMap(doc[key]) {
+			def.Schemas[name] = map[string]interface{}(ramlSchema(t))
+		}
+	}
+	addRAMLResources(def, "", doc)
+	return def, nil
+}
+
+func addRAMLResources(def *apiDefinition, resourcePath string, resource schema) {
+	for key, value := range resource {
+		if strings.HasPrefix(key, "/") {
+			addRAMLResources(def, resourcePath+key, schemaMap(value))
+			continue
+		}
+		if resourcePath == "" || !utils.Contains(key, httpMethods...) {
+			continue
+		}
+		method := schemaMap(value)
+		operation := apiOperation{Parameters: map[string]apiParameter{}, Responses: map[string]schema{}}
+		for _, in := range []string{"queryParameters", "headers"} {
+			for name, p := range schemaMap(method[in]) {
+				name, parameter := ramlParameter(name, p)
+				operation.Parameters[strings.TrimSuffix(in, "Parameters")+" "+name] = parameter
+			}
+		}
+		operation.Request = ramlBody(method["body"])
+		for code, r := range schemaMap(method["responses"]) {
+			if isSuccessStatus(code) {
+				operation.Responses[code] = ramlBody(schemaMap(r)["body"])
+			}
+		}
+		def.Operations[strings.ToUpper(key)+" "+resourcePath] = operation
+	}
+}
+
+// ramlParameter returns the name and the parameter, required by default unless the name ends with '?'.
+func ramlParameter(name string, v interface{}) (string, apiParameter) {
+	s := ramlSchema(v)
+	p := apiParameter{Required: !strings.HasSuffix(name, "?"), Type: schemaString(s, "type")}
+	if p.Type == "" {
+		p.Type = "string"
+	}
+	if required, ok := schemaMap(v)["required"].(bool); ok {
+		p.Required = required
+	}
+	return strings.TrimSuffix(name, "?"), p
+}
+
+func ramlBody(v interface{}) schema {
+	if v == nil {
+		return nil
+	}
+	body := schemaMap(v)
+	if _, ok := body["type"]; ok {
+		return ramlSchema(body)
+	}
+	if _, ok := body["properties"]; ok {
+		return ramlSchema(body)
+	}
+	if content, ok := preferredContent(body); ok {
+		return ramlSchema(content)
+	}
+	return schema{}
+}
+
+// ramlSchema converts a RAML type declaration, e.g. 'string' or {properties: ...}, to a JSON schema.
+func ramlSchema(v interface{}) schema {
+	if s, ok := v.(string); ok {
+		return schema{"type": s}
+	}
+	t := schemaMap(v)
+	s := schema{}
+	for _, key := range []string{"type", "format", "enum"} {
+		if value, ok := t[key]; ok {
+			s[key] = value
+		}
+	}
+	if properties := schemaMap(t["properties"]); properties != nil {
+		if _, ok := s["type"]; !ok {
+			s["type"] = "object"
+		}
+		converted := map[string]interface{}{}
+		var required []interface{}
+		for name, p := range properties {
+			isRequired := !strings.HasSuffix(name, "?")
+			if r, ok := schemaMap(p)["required"].(bool); ok {
+				isRequired = r
+			}
+			name = strings.TrimSuffix(name, "?")
+			converted[name] = map[string]interface{}(ramlSchema(p))
+			if isRequired {
+				required = append(required, name)
+			}
+		}
+		s["properties"] = converted
+		s["required"] = required
+	}
+	if items, ok := t["items"]; ok {
+		s["items"] = map[string]interface{}(ramlSchema(items))
+	}
+	return s
+}
+
+// protoDefinition holds the services and messages of a .proto file.
+type protoDefinition struct {
+	// RPCs by service and method, e.g. 'Pets.Get' -> 'GetPetRequest -> Pet'.
+	RPCs map[string]string
+	// Messages by name, e.g. 'Pet' or 'Pet.Owner' for the nested ones.
+	Messages map[string]map[string]protoField
+}
+
+type protoField struct {
+	Type, Number string
+}
+
+var (
+	protoBlockPattern = regexp.MustCompile(`^\s*(message|service|enum|oneof)\s+(\w+)\s*\{`)
+	protoRPCPattern   = regexp.MustCompile(`^\s*rpc\s+(\w+)\s*\(\s*(stream\s+)?([\w.]+)\s*\)\s*returns\s*\(\s*(stream\s+)?([\w.]+)\s*\)`)
+	protoFieldPattern = regexp.MustCompile(`^\s*(repeated\s+|optional\s+|required\s+)?(map\s*<[^>]+>|[\w.]+)\s+(\w+)\s*=\s*(\d+)`)
+)
+
+type protoScope struct {
+	kind, name string
+}
+
+// parseProto extracts the services and messages, the options and the enum values are ignored.
+func parseProto(data []byte) *protoDefinition {
+	def := &protoDefinition{RPCs: map[string]string{}, Messages: map[string]map[string]protoField{}}
+	var scopes []protoScope
+	for _, line := range strings.Split(string(data), "\n") {
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		opened := strings.Count(line, "{")
+		current := protoScope{}
+		if len(scopes) > 0 {
+			current = scopes[len(scopes)-1]
+		}
+		if m := protoBlockPattern.FindStringSubmatch(line); m != nil {
+			scopes = append(scopes, protoScope{m[1], m[2]})
+			opened--
+			if m[1] == "message" {
+				def.Messages[protoMessageName(scopes)] = map[string]protoField{}
+			}
+		} else if m := protoRPCPattern.FindStringSubmatch(line); m != nil && current.kind == "service" {
+			def.RPCs[current.name+"."+m[1]] = strings.TrimSpace(m[2]+m[3]) + " -> " + strings.TrimSpace(m[4]+m[5])
+		} else if m := protoFieldPattern.FindStringSubmatch(line); m != nil && (current.kind == "message" || current.kind == "oneof") && m[2] != "option" {
+			def.Messages[protoMessageName(scopes)][m[3]] = protoField{Type: strings.TrimSpace(m[1] + m[2]), Number: m[4]}
+		}
+		for i := 0; i < opened; i++ {
+			scopes = append(scopes, protoScope{})
+		}
+		for i := strings.Count(line, "}"); i > 0 && len(scopes) > 0; i-- {
+			scopes = scopes[:len(scopes)-1]
+		}
+	}
+	return def
+}
+
+// protoMessageName returns the name of the innermost message, e.g. 'Pet.Owner'.
+func protoMessageName(scopes []protoScope) string {
+	var names []string
+	for _, s := range scopes {
+		if s.kind == "message" {
+			names = append(names, s.name)
+		}
+	}
+	return strings.Join(names, ".")
+}
+
+func diffProto(base, head *protoDefinition) (changes APIChanges) {
+	for rpc, b := range base.RPCs {
+		h, ok := head.RPCs[rpc]
+		if !ok {
+			changes = append(changes, APIChange{Breaking: true, Location: rpc, Message: "rpc removed"})
+		} else if b != h {
+			changes = append(changes, APIChange{Breaking: true, Location: rpc, Message: fmt.Sprintf("signature changed from '%v' to '%v'", b, h)})
+		}
+	}
+	for rpc := range head.RPCs {
+		if _, ok := base.RPCs[rpc]; !ok {
+			changes = append(changes, APIChange{Location: rpc, Message: "rpc added"})
+		}
+	}
+	for name, baseFields := range base.Messages {
+		headFields, ok := head.Messages[name]
+		if !ok {
+			changes = append(changes, APIChange{Breaking: true, Location: name, Message: "message removed"})
+			continue
+		}
+		for field, b := range baseFields {
+			h, ok := headFields[field]
+			switch {
+			case !ok:
+				changes = append(changes, APIChange{Breaking: true, Location: name + "." + field, Message: "field removed"})
+			case b.Number != h.Number:
+				changes = append(changes, APIChange{Breaking: true, Location: name + "." + field, Message: fmt.Sprintf("number changed from %v to %v", b.Number, h.Number)})
+			case b.Type != h.Type:
+				changes = append(changes, APIChange{Breaking: true, Location: name + "." + field, Message: fmt.Sprintf("type changed from '%v' to '%v'", b.Type, h.Type)})
+			}
+		}
+		for field := range headFields {
+			if _, ok := baseFields[field]; !ok {
+				changes = append(changes, APIChange{Location: name + "." + field, Message: "field added"})
+			}
+		}
+	}
+	for name := range head.Messages {
+		if _, ok := base.Messages[name]; !ok {
+			changes = append(changes, APIChange{Location: name, Message: "message added"})
+		}
+	}
+	return changes
+}
@@ -0,0 +1,259 @@
+package core
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func breakingChanges(changes APIChanges) (messages []string) {
+	for _, c := range changes {
+		if c.Breaking {
+			messages = append(messages, c.Location+": "+c.Message)
+		}
+	}
+	return messages
+}
+
+func TestDiffOpenAPI(t *testing.T) {
+	base := `openapi: 3.0.0
+paths:
+  /pets:
+    get:
+      parameters:
+        - {name: limit, in: query, schema: {type: integer}}
+      responses:
+        200:
+          content:
+            application/json:
+              schema:
+                type: array
+                items: {$ref: '#/components/schemas/Pet'}
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [name]
+              properties:
+                name: {type: string}
+                tag: {type: string}
+      responses:
+        '201': {description: created}
+  /pets/{id}:
+    delete:
+      responses:
+        '204': {description: deleted}
+components:
+  schemas:
+    Pet:
+      type: object
+      properties:
+        id: {type: integer}
+        name: {type: string}
+`
+	head := `openapi: 3.0.0
+paths:
+  /pets:
+    get:
+      parameters:
+        - {name: limit, in: query, required: true, schema: {type: string}}
+        - {name: offset, in: query, schema: {type: integer}}
+      responses:
+        200:
+          content:
+            application/json:
+              schema:
+                type: array
+                items: {$ref: '#/components/schemas/Pet'}
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [name, owner]
+              properties:
+                name: {type: string}
+                owner: {type: string}
+      responses:
+        '201': {description: created}
+  /owners:
+    get:
+      responses:
+        '200': {description: ok}
+components:
+  schemas:
+    Pet:
+      type: object
+      properties:
+        id: {type: string}
+        birthday: {type: string, format: date}
+`
+	changes, err := DiffAPI("openapi", []byte(base), []byte(head))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"DELETE /pets/{id}: endpoint removed",
+		"GET /pets: parameter 'query limit' is now required",
+		"GET /pets: parameter 'query limit' type changed from 'integer' to 'string'",
+		"POST /pets request.owner: required property added",
+		"Pet.id: type changed from 'integer' to 'string'",
+		"Pet.name: property removed",
+	}, breakingChanges(changes))
+	assert.Equal(t, 6, changes.CountBreaking())
+	assert.Contains(t, changes, APIChange{Location: "GET /owners", Message: "endpoint added"})
+	assert.Contains(t, changes, APIChange{Location: "GET /pets", Message: "optional parameter 'query offset' added"})
+	assert.Contains(t, changes, APIChange{Location: "POST /pets request.tag", Message: "property removed"})
+}
+
+func TestDiffProtocolsFromForkPoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bub")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	g := MustInitGit(dir)
+	commit := func(message string) {
+		assert.NoError(t, g.RunGit("add", "-A"))
+		assert.NoError(t, g.RunGit("-c", "user.name=bub", "-c", "user.email=bub@example.com", "commit", "-q", "-m", message))
+	}
+	assert.NoError(t, g.RunGit("init", "-q"))
+	assert.NoError(t, g.RunGit("symbolic-ref", "HEAD", "refs/heads/master"))
+	pets := "openapi: 3.0.0\npaths:\n  /pets:\n    get:\n      responses:\n        '200': {description: ok}\n"
+	writeFiles(t, dir, map[string]string{"api/pets.yml": pets})
+	commit("pets")
+
+	assert.NoError(t, g.RunGit("checkout", "-q", "-b", "feature"))
+	writeFiles(t, dir, map[string]string{"api/pets.yml": pets + "  /owners:\n    get:\n      responses:\n        '200': {description: ok}\n"})
+	commit("owners")
+
+	// the base branch moved ahead after the fork.
+	assert.NoError(t, g.RunGit("checkout", "-q", "master"))
+	writeFiles(t, dir, map[string]string{
+		"api/pets.yml":   pets + "  /stores:\n    get:\n      responses:\n        '200': {description: ok}\n",
+		"api/stores.yml": "openapi: 3.0.0\npaths: {}\n",
+	})
+	commit("stores")
+	assert.NoError(t, g.RunGit("checkout", "-q", "feature"))
+
+	m := &Manifest{Protocols: []Protocol{{Type: "openapi", Path: "api"}}}
+	expected := APIChanges{{File: "api/pets.yml", Location: "GET /owners", Message: "endpoint added"}}
+	changes, err := DiffProtocols(g, m, "master")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, changes)
+
+	// in a CI checkout, only the remote branch exists.
+	assert.NoError(t, g.RunGit("update-ref", "refs/remotes/origin/master", "master"))
+	assert.NoError(t, g.RunGit("branch", "-q", "-D", "master"))
+	changes, err = DiffProtocols(g, m, "master")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, changes)
+}
+
+func TestDiffRAML(t *testing.T) {
+	base := `#%RAML 1.0
+title: Pets
+types:
+  Pet:
+    properties:
+      name: string
+      status:
+        enum: [available, sold]
+/pets:
+  get:
+    queryParameters:
+      limit?: integer
+    responses:
+      200:
+        body:
+          application/json:
+            type: Pet[]
+  /{id}:
+    put:
+      body:
+        application/json:
+          properties:
+            name: string
+`
+	head := `#%RAML 1.0
+title: Pets
+types:
+  Pet:
+    properties:
+      name: string
+      status:
+        enum: [available, pending, sold]
+/pets:
+  get:
+    queryParameters:
+      limit?: integer
+      owner: string
+    responses:
+      200:
+        body:
+          application/json:
+            type: Pet[]
+  /{id}:
+    put:
+      body:
+        application/json:
+          properties:
+            name: string
+            tag?: string
+`
+	changes, err := DiffAPI("raml", []byte(base), []byte(head))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"GET /pets: required parameter 'query owner' added",
+		"Pet.status: enum value 'pending' added",
+	}, breakingChanges(changes))
+	assert.Contains(t, changes, APIChange{Location: "PUT /pets/{id} request.tag", Message: "optional property added"})
+}
+
+func TestDiffProto(t *testing.T) {
+	base := `syntax = "proto3";
+
+service Pets {
+  rpc Get(GetPetRequest) returns (Pet);
+  rpc Delete(DeletePetRequest) returns (Empty) {}
+}
+
+message Pet {
+  string id = 1;
+  string name = 2; // the display name
+  message Owner {
+    string email = 1;
+  }
+  oneof tag {
+    string label = 3;
+  }
+}
+`
+	head := `syntax = "proto3";
+
+service Pets {
+  rpc Get(GetPetRequest) returns (stream Pet);
+  rpc List(ListPetsRequest) returns (ListPetsResponse);
+}
+
+message Pet {
+  int64 id = 1;
+  string name = 4;
+  repeated string aliases = 5;
+  message Owner {
+  }
+}
+`
+	changes, err := DiffAPI("proto", []byte(base), []byte(head))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"Pet.Owner.email: field removed",
+		"Pet.id: type changed from 'string' to 'int64'",
+		"Pet.label: field removed",
+		"Pet.name: number changed from 2 to 4",
+		"Pets.Delete: rpc removed",
+		"Pets.Get: signature changed from 'GetPetRequest -> Pet' to 'GetPetRequest -> stream Pet'",
+	}, breakingChanges(changes))
+	assert.Contains(t, changes, APIChange{Location: "Pet.aliases", Message: "field added"})
+	assert.Contains(t, changes, APIChange{Location: "Pets.List", Message: "rpc added"})
+}
@@ -0,0 +1,243 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/j-martin/bub/utils"
+	"gopkg.in/yaml.v3"
+	"io"
+	"log"
+	"path"
+	"strings"
+)
+
+// The mapping between the manifests and the Backstage entities is documented in docs/backstage.md.
+const (
+	backstageAPIVersion       = "backstage.io/v1alpha1"
+	backstageSlugAnnotation   = "github.com/project-slug"
+	backstageSourceAnnotation = "backstage.io/source-location"
+	backstagePageAnnotation   = "bub/confluence-page-id"
+)
+
+type BackstageEntity struct {
+	APIVersion string            `yaml:"apiVersion"`
+	Kind       string            `yaml:"kind"`
+	Metadata   BackstageMetadata `yaml:"metadata"`
+	Spec       BackstageSpec     `yaml:"spec"`
+}
+
+type BackstageMetadata struct {
+	Name        string            `yaml:"name"`
+	Description string            `yaml:"description,omitempty"`
+	Tags        []string          `yaml:"tags,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+// BackstageSpec holds the fields of the Component, API and Resource kinds.
+type BackstageSpec struct {
+	Type         string            `yaml:"type"`
+	Lifecycle    string            `yaml:"lifecycle,omitempty"`
+	Owner        string            `yaml:"owner"`
+	DependsOn    []string          `yaml:"dependsOn,omitempty"`
+	ProvidesApis []string          `yaml:"providesApis,omitempty"`
+	Definition   map[string]string `yaml:"definition,omitempty"`
+}
+
+// backstageComponentTypes maps the first manifest type to the component type.
+var backstageComponentTypes = map[string]string{"service": "service", "front-end": "website", "library": "library"}
+
+// backstageAPITypes maps the protocol types to the API types.
+var backstageAPITypes = map[string]string{"proto": "grpc", "openapi": "openapi", "raml": "raml", "graphql": "graphql"}
+
+// backstageDefinitionExtensions are the extensions of the API definition files, the other protocol paths
+// are directories (e.g. of .proto files) which cannot be used as definition.
+var backstageDefinitionExtensions = []string{".yaml", ".yml", ".json", ".raml", ".proto", ".graphql", ".gql"}
+
+func reverseMapping(m map[string]string) map[string]string {
+	reversed := make(map[string]string)
+	for k, v := range m {
+		reversed[v] = k
+	}
+	return reversed
+}
+
+// backstageOwner returns the owner of the manifest directory (or of the whole repository) from the CODEOWNERS rules.
+func backstageOwner(m Manifest) string {
+	for _, rule := range []string{m.Dir + "/", "/" + m.Dir + "/", "*"} {
+		for _, u := range m.Owners[rule] {
+			switch {
+			case strings.Contains(u.GitHub, "/"):
+				return "group:" + u.GitHub[strings.Index(u.GitHub, "/")+1:]
+			case u.GitHub != "":
+				return "user:" + u.GitHub
+			case u.Email != "":
+				return "user:" + strings.Split(u.Email, "@")[0]
+			}
+		}
+	}
+	return "unknown"
+}
+
+// ExportBackstage converts the manifest to a Backstage Component, with an API entity per protocol
+// and a Resource entity per dependency that is not a service.
+func ExportBackstage(m Manifest, cfg *Configuration, owner string) []BackstageEntity {
+	if owner == "" {
+		owner = backstageOwner(m)
+	}
+	componentType := "service"
+	if len(m.Types) > 0 {
+		if t, ok := backstageComponentTypes[m.Types[0]]; ok {
+			componentType = t
+		} else {
+			componentType = m.Types[0]
+		}
+	}
+	baseBranch := m.BaseBranch
+	if baseBranch == "" {
+		baseBranch = "master"
+	}
+	lifecycle := "production"
+	if !m.Active {
+		lifecycle = "deprecated"
+	}
+	component := BackstageEntity{
+		APIVersion: backstageAPIVersion,
+		Kind:       "Component",
+		Metadata: BackstageMetadata{
+			Name: m.Name,
+			Tags: m.Languages,
+			Annotations: map[string]string{
+				backstageSlugAnnotation:   path.Join(cfg.GitHub.Organization, m.Repository),
+				backstageSourceAnnotation: "url:" + cfg.GitHubURL(cfg.GitHub.Organization, m.Repository, "tree", baseBranch, m.Dir) + "/",
+			},
+		},
+		Spec: BackstageSpec{Type: componentType, Lifecycle: lifecycle, Owner: owner},
+	}
+	if m.Documentation.PageId != "" {
+		component.Metadata.Annotations[backstagePageAnnotation] = m.Documentation.PageId
+	}
+
+	var entities []BackstageEntity
+	for _, d := range m.Dependencies {
+		name := dependencyNodeName(m, d)
+		if d.Type == "" || d.Type == "service" || d.Type == "front-end" {
+			component.Spec.DependsOn = append(component.Spec.DependsOn, "component:"+name)
+			continue
+		}
+		component.Spec.DependsOn = append(component.Spec.DependsOn, "resource:"+name)
+		entities = append(entities, BackstageEntity{
+			APIVersion: backstageAPIVersion,
+			Kind:       "Resource",
+			Metadata:   BackstageMetadata{Name: name, Description: d.Description},
+			Spec:       BackstageSpec{Type: d.Type, Owner: owner},
+		})
+	}
+	apis := 0
+	for _, p := range m.Protocols {
+		if !utils.Contains(path.Ext(p.Path), backstageDefinitionExtensions...) {
+			log.Printf("Skipping the %v protocol, Backstage needs a definition file but '%v' is a directory.", p.Type, p.Path)
+			continue
+		}
+		apiType, ok := backstageAPITypes[p.Type]
+		if !ok {
+			apiType = p.Type
+		}
+		name := m.Name + "-" + apiType
+		if apis++; apis > 1 {
+			name = fmt.Sprintf("%v-%v", name, apis)
+		}
+		component.Spec.ProvidesApis = append(component.Spec.ProvidesApis, name)
+		entities = append(entities, BackstageEntity{
+			APIVersion: backstageAPIVersion,
+			Kind:       "API",
+			Metadata:   BackstageMetadata{Name: name},
+			Spec: BackstageSpec{
+				Type:       apiType,
+				Lifecycle:  lifecycle,
+				Owner:      owner,
+				Definition: map[string]string{"$text": "./" + p.Path},
+			},
+		})
+	}
+	return append([]BackstageEntity{component}, entities...)
+}
+
+// WriteBackstage writes the entities as a multi-document catalog-info.yaml.
+func WriteBackstage(w io.Writer, entities []BackstageEntity) error {
+	if _, err := fmt.Fprintln(w, "---"); err != nil {
+		return err
+	}
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	for _, e := range entities {
+		if err := encoder.Encode(e); err != nil {
+			return err
+		}
+	}
+	return encoder.Close()
+}
+
+// ImportBackstage converts the Component of a catalog-info.yaml to a manifest. The APIs and the Resources
+// defined in the same file are used for the protocols and the dependency types.
+func ImportBackstage(data []byte) (*Manifest, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	entities := map[string]BackstageEntity{}
+	var component *BackstageEntity
+	for {
+		var e BackstageEntity
+		err := decoder.Decode(&e)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if e.Kind == "Component" && component == nil {
+			component = &e
+			continue
+		}
+		entities[strings.ToLower(e.Kind)+":"+e.Metadata.Name] = e
+	}
+	if component == nil {
+		return nil, fmt.Errorf("no Component found in the catalog")
+	}
+
+	m := &Manifest{
+		Name:      component.Metadata.Name,
+		Active:    component.Spec.Lifecycle != "deprecated",
+		Languages: component.Metadata.Tags,
+	}
+	manifestType, ok := reverseMapping(backstageComponentTypes)[component.Spec.Type]
+	if !ok {
+		manifestType = component.Spec.Type
+	}
+	m.Types = []string{manifestType}
+	m.Documentation.PageId = component.Metadata.Annotations[backstagePageAnnotation]
+
+	for _, ref := range component.Spec.DependsOn {
+		kind, name := "component", ref
+		if i := strings.Index(ref, ":"); i >= 0 {
+			kind, name = strings.ToLower(ref[:i]), ref[i+1:]
+		}
+		d := Dependency{Name: name, Type: "service"}
+		if kind == "resource" {
+			resource := entities[kind+":"+name]
+			d.Type, d.Description = resource.Spec.Type, resource.Metadata.Description
+		}
+		m.Dependencies = append(m.Dependencies, d)
+	}
+	apiTypes := reverseMapping(backstageAPITypes)
+	for _, name := range component.Spec.ProvidesApis {
+		api, ok := entities["api:"+strings.TrimPrefix(name, "api:")]
+		if !ok || api.Spec.Definition["$text"] == "" {
+			continue
+		}
+		protocolType, ok := apiTypes[api.Spec.Type]
+		if !ok {
+			protocolType = api.Spec.Type
+		}
+		m.Protocols = append(m.Protocols, Protocol{Type: protocolType, Path: strings.TrimPrefix(api.Spec.Definition["$text"], "./")})
+	}
+	m.normalize()
+	return m, nil
+}
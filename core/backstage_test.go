@@ -0,0 +1,73 @@
+package core
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestBackstageRoundTrip(t *testing.T) {
+	m := Manifest{
+		Name:       "billing",
+		Repository: "platform",
+		Dir:        "services/billing",
+		Active:     true,
+		Languages:  []string{"go"},
+		Types:      []string{"service"},
+		Dependencies: []Dependency{
+			{Name: "postgres", Type: "database", Description: "invoices"},
+			{Name: "mainapp", Type: "service"},
+		},
+		Protocols:     []Protocol{{Type: "proto", Path: "api/proto"}, {Type: "openapi", Path: "api/openapi.yaml"}},
+		Documentation: Documentation{PageId: "12345"},
+		Owners:        Ownership{"*": {{GitHub: "benchlabs/payments"}}},
+	}
+	cfg := &Configuration{}
+	cfg.GitHub.Organization = "benchlabs"
+	cfg.GitHub.Host = "github.example.com"
+	entities := ExportBackstage(m, cfg, "")
+	assert.Len(t, entities, 3)
+	component := entities[0]
+	assert.Equal(t, "group:payments", component.Spec.Owner)
+	assert.Equal(t, []string{"resource:postgres", "component:mainapp"}, component.Spec.DependsOn)
+	assert.Equal(t, []string{"billing-openapi"}, component.Spec.ProvidesApis)
+	assert.Equal(t, "benchlabs/platform", component.Metadata.Annotations[backstageSlugAnnotation])
+	assert.Equal(t, "url:https://github.example.com/benchlabs/platform/tree/master/services/billing/", component.Metadata.Annotations[backstageSourceAnnotation])
+
+	var buf bytes.Buffer
+	assert.NoError(t, WriteBackstage(&buf, entities))
+	assert.Contains(t, buf.String(), "kind: Component")
+	assert.Contains(t, buf.String(), "$text: ./api/openapi.yaml")
+
+	imported, err := ImportBackstage(buf.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, "billing", imported.Name)
+	assert.True(t, imported.Active)
+	assert.Equal(t, []string{"go"}, imported.Languages)
+	assert.Equal(t, []string{"service"}, imported.Types)
+	assert.Equal(t, []Dependency{
+		{Name: "postgres", Type: "database", Description: "invoices"},
+		{Name: "mainapp", Type: "service"},
+	}, imported.Dependencies)
+	assert.Equal(t, m.Protocols[1:], imported.Protocols)
+	assert.Equal(t, "12345", imported.Documentation.PageId)
+
+	content, err := renderManifest(*imported)
+	assert.NoError(t, err)
+	assert.Contains(t, content, `pageId: "12345"`)
+	assert.Contains(t, content, `description: "invoices"`)
+	assert.Contains(t, content, "\nactive: true\n")
+
+	m.Active = false
+	buf.Reset()
+	assert.NoError(t, WriteBackstage(&buf, ExportBackstage(m, cfg, "")))
+	imported, err = ImportBackstage(buf.Bytes())
+	assert.NoError(t, err)
+	assert.False(t, imported.Active)
+	content, err = renderManifest(*imported)
+	assert.NoError(t, err)
+	assert.Contains(t, content, "\nactive: false\n")
+
+	_, err = ImportBackstage([]byte("kind: API\nmetadata:\n  name: billing-openapi\n"))
+	assert.Error(t, err)
+}
@@ -4,8 +4,6 @@ import (
 	"fmt"
 	"github.com/imdario/mergo"
 	"github.com/j-martin/bub/utils"
-	"github.com/manifoldco/promptui"
-	"github.com/tmc/keyring"
 	"gopkg.in/yaml.v2"
 	"io/ioutil"
 	"log"
@@ -16,8 +14,9 @@ import (
 )
 
 const (
-	ConfigUserFile   = "config.yml"
-	ConfigSharedFile = "shared.yml"
+	ConfigUserFile    = "config.yml"
+	ConfigSharedFile  = "shared.yml"
+	ConfigProfilesDir = "profiles"
 )
 
 type Environment struct {
@@ -31,49 +30,105 @@ type User struct {
 }
 
 type Configuration struct {
-	Git struct {
+	// Version of the config format, see 'bub config migrate'.
+	Version int
+	Git     struct {
 		NoVerify bool `yaml:"noVerify"`
+		// BaseBranch is used when the repository has no base branch in its .bub.yml and no origin/HEAD.
+		BaseBranch string `yaml:"baseBranch"`
 	}
 	GitHub struct {
 		Organization, Username, Token string
 		Reviewers                     []string
+		Enabled                       *bool
+		// Host of GitHub Enterprise, e.g. github.example.com, github.com by default.
+		Host string
+		// CloneProtocol of the repositories, ssh (default) or https.
+		CloneProtocol string `yaml:"cloneProtocol"`
 	}
 	Users []User
 	JIRA  struct {
 		Server, Username, Password string
 		Project, Board             string
 		Transitions                []JIRATransition
-		Enabled                    bool
+		Enabled                    *bool
 	}
 	Jenkins    ServiceConfiguration
 	Confluence ServiceConfiguration
+	Splunk     ServiceConfiguration
 	Vault      struct {
 		AuthMethod, Server, Username, Password, Path string
+		Enabled                                      *bool
 	}
 	Ssh struct {
 		ConnectTimeout uint `yaml:"connectTimeout"`
 	}
+	Credentials struct {
+		// keyring (default), env, command or file.
+		Backend string
+		// backend per item, e.g. "GitHub Token". Run 'bub credentials list' to see the items.
+		Items map[string]CredentialBackendConfiguration
+	}
+	SharedConfig struct {
+		// git repository or local directory where the team's shared config is published.
+		Source string
+		// path of the shared config within the source, defaults to shared.yml.
+		Path string
+	} `yaml:"sharedConfig"`
 	ResetCredentials bool
+	// Profile is the name of the active profile, if any. Set at runtime, never read from the files.
+	Profile string `yaml:"-"`
+	// Sources maps each value (e.g. jira.server) to where it was loaded from.
+	Sources map[string]string `yaml:"-"`
 }
 
 type JIRATransition struct {
 	Name, Alias string
 }
 
+type CredentialBackendConfiguration struct {
+	// keyring, env, command or file.
+	Backend string
+	// env: name of the variable, e.g. GITHUB_TOKEN.
+	Env string
+	// command: prints the secret, e.g. 'pass show bub/github-token'.
+	Command string
+	// file: age (.age) or GPG encrypted file containing the secret.
+	File string
+	// file: age identity used to decrypt the file.
+	Identity string
+	// file: age or GPG recipient used to encrypt the file when the secret is stored.
+	Recipient string
+}
+
 type ServiceConfiguration struct {
 	Server, Username, Password string
+	Enabled                    *bool
 }
 
 var config = `---
 # use 'bub config --shared' to edit the shared config.
+version: 2
+
+git:
+	# base branch of the repositories without origin/HEAD (default: master), a repository can set it in its .bub.yml.
+	# baseBranch: main
+
 github:
 	organization: benchlabs
 	reviewers:
 		# - reviewers (GitHub username) that will be applied to the PRs by default.
+	# GitHub Enterprise host (default: github.com).
+	# host: github.example.com
+	# protocol used to clone the repositories, ssh (default) or https.
+	# cloneProtocol: https
 
 jenkins:
 	server: "https://jenkins.example..com"
 
+splunk:
+	server: "https://splunk.example..com"
+
 vault:
 	server: "https://vault.example..com"
 	path: "/secret/tool/bub"
@@ -84,21 +139,43 @@ confluence:
 jira:
 	server: "https://example.atlassian.net"
 	project: # default project to use when creating issues.
-	board: id of the board when creating issues in the current sprint.
+	board: # id of the board when creating issues in the current sprint.
 
 ssh:
 	connectTimeout: 3
+
+credentials:
+	# where the credentials are stored: keyring (default), env, command or file.
+	backend: keyring
+	# items:
+	#   GitHub Token:
+	#     backend: command
+	#     command: pass show bub/github-token
+	#   JIRA Password:
+	#     backend: file
+	#     file: ~/.config/bub/jira.age
+	#     identity: ~/.config/age/key.txt
+	#     recipient: age1...
+
+sharedConfig:
+	# git repository (e.g. git@github.com:benchlabs/bub-config.git) or local directory
+	# used by 'bub config --load-shared-config' and 'bub config --store-shared-config'.
+	source:
 `
 
 func GetConfigString() string {
 	return strings.Replace(config, "\t", "  ", -1)
 }
 
-func LoadConfiguration() (*Configuration, error) {
+// LoadConfiguration merges the shared and user configuration files. If a profile is passed,
+// the profile file (e.g. ~/.config/bub/profiles/<profile>.yml) is applied on top of them.
+// Then, the repository's .bub.yml and the environment variables are applied.
+func LoadConfiguration(profile string) (*Configuration, error) {
 	baseCfg, err := loadConfiguration(ConfigSharedFile)
 	if err != nil && err != utils.FileDoesNotExist {
 		return nil, err
 	}
+	sharedCfg := *baseCfg
 	cfg, err := loadConfiguration(ConfigUserFile)
 	if err != nil {
 		return nil, err
@@ -107,6 +184,35 @@ func LoadConfiguration() (*Configuration, error) {
 	if err != nil {
 		return nil, err
 	}
+	// ordered by precedence, the shared values are not overridden by the user config.
+	layers := []configLayer{{ConfigSharedFile, &sharedCfg}, {ConfigUserFile, cfg}}
+	var profileFields []string
+	if profile != "" {
+		profileCfg, profileData, err := loadConfigurationData(GetProfileConfigFile(profile))
+		if err == utils.FileDoesNotExist {
+			log.Printf("The profile '%v' has no config file, create it with 'bub --profile %v config'.", profile, profile)
+		} else if err != nil {
+			return nil, err
+		}
+		profileFields, err = mergeProfileConfiguration(baseCfg, profileCfg, profileData)
+		if err != nil {
+			return nil, err
+		}
+		baseCfg.Profile = profile
+		layers = append([]configLayer{{GetProfileConfigFile(profile), profileCfg}}, layers...)
+	}
+	baseCfg.Sources = resolveSources(baseCfg, layers)
+	for _, f := range profileFields {
+		baseCfg.setSource(f, GetProfileConfigFile(profile))
+	}
+	if utils.InRepository() {
+		if err = baseCfg.applyRepositoryConfiguration("."); err != nil {
+			return nil, err
+		}
+	}
+	if err = baseCfg.applyEnvironment(); err != nil {
+		return nil, err
+	}
 	resetCredentials := os.Getenv("BUB_UPDATE_CREDENTIALS")
 	if resetCredentials != "" {
 		baseCfg.ResetCredentials = true
@@ -114,6 +220,11 @@ func LoadConfiguration() (*Configuration, error) {
 	return baseCfg, nil
 }
 
+// GetProfileConfigFile returns the config file of a profile, relative to the bub config directory.
+func GetProfileConfigFile(profile string) string {
+	return path.Join(ConfigProfilesDir, profile+".yml")
+}
+
 func getConfigPath(configFile string) (string, error) {
 	usr, err := user.Current()
 	if err != nil {
@@ -125,30 +236,46 @@ func getConfigPath(configFile string) (string, error) {
 }
 
 func loadConfiguration(configFile string) (*Configuration, error) {
+	cfg, _, err := loadConfigurationData(configFile)
+	return cfg, err
+}
+
+// loadConfigurationData is loadConfiguration, it also returns the migrated content of the file.
+func loadConfigurationData(configFile string) (*Configuration, []byte, error) {
 	cfg := &Configuration{}
 	configPath, err := getConfigPath(configFile)
 	if err != nil {
-		return cfg, err
+		return cfg, nil, err
 	}
 	fileExists, _ := utils.PathExists(configPath)
 	if !fileExists {
-		return cfg, utils.FileDoesNotExist
+		return cfg, nil, utils.FileDoesNotExist
 	}
 
 	data, err := ioutil.ReadFile(configPath)
 	if err != nil {
 		log.Print("No bub configuration found. Please run `bub setup`")
-		return cfg, err
+		return cfg, nil, err
+	}
+	data, version, err := migrateConfigurationData(data)
+	if err != nil {
+		return cfg, nil, fmt.Errorf("%v: %v", configPath, err)
 	}
+	warnOutdatedConfiguration(configPath, version)
 
 	err = yaml.Unmarshal(data, &cfg)
-	return cfg, err
+	return cfg, data, err
 }
 
 func EditConfiguration(configFile string) error {
 	return utils.CreateAndEdit(GetConfigPath(configFile), GetConfigString())
 }
 
+func EditProfileConfiguration(profile string) error {
+	content := fmt.Sprintf("---\n# Profile '%v', the values defined here override the shared and user config.\nversion: %v\n", profile, ConfigVersion)
+	return utils.CreateAndEdit(GetConfigPath(GetProfileConfigFile(profile)), content)
+}
+
 func GetConfigPath(configFile string) string {
 	usr, err := user.Current()
 	if err != nil {
@@ -165,86 +292,75 @@ func MustSetupConfig() {
 	}
 }
 
+// CreateConfiguration writes the base config without opening the editor, if it does not exist yet.
+func CreateConfiguration() error {
+	configPath := GetConfigPath(ConfigUserFile)
+	if exists, err := utils.PathExists(configPath); err != nil || exists {
+		return err
+	}
+	if err := os.MkdirAll(path.Dir(configPath), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(configPath, []byte(GetConfigString()), 0600)
+}
+
 func ShowConfig(cfg *Configuration) error {
-	yml, _ := yaml.Marshal(cfg)
+	redacted := *cfg
+	for _, f := range listConfigFields(&redacted) {
+		if isSecretField(f.Path) && f.Value.String() != "" {
+			f.Value.SetString(redactedValue)
+		}
+	}
+	yml, _ := yaml.Marshal(redacted)
 	fmt.Println(string(yml))
 	return nil
 }
 
 func CheckServerConfig(server string) {
 	if server == "" {
-		log.Fatal("Server cannot be empty, make sure the config file is properly configured. run 'bub config'.")
+		log.Fatal("Server cannot be empty, make sure the config file is properly configured. run 'bub config' and 'bub config validate'.")
 	}
 }
 
-func LoadCredentials(item string, username, password *string, resetCredentials bool) (err error) {
-	if err = LoadCredentialItem(item+" Username", username, resetCredentials); err != nil {
-		return err
-	}
-	if err = LoadCredentialItem(item+" Password", password, resetCredentials); err != nil {
-		return err
-	}
-	return nil
+func equalAndNotEmpty(a, b string) bool {
+	return a != "" && a == b
 }
 
-func LoadCredentialItem(item string, ptr *string, resetCredentials bool) (err error) {
-	if resetCredentials {
-		return setKeyringItem(item, ptr)
-	}
-	// e.g. "Confluence Username" -> "CONFLUENCE_USERNAME"
-	envVar := os.Getenv(strings.Replace(strings.ToUpper(item), " ", "_", -1))
-	if envVar != "" {
-		*ptr = envVar
-		return
-	}
-
-	if *ptr != "" && !strings.HasPrefix(*ptr, "<optional-") {
-		return nil
+// GitHubHost returns the host of GitHub, github.com unless GitHub Enterprise is configured.
+func (cfg *Configuration) GitHubHost() string {
+	if cfg.GitHub.Host == "" {
+		return "github.com"
 	}
-
-	return LoadKeyringItem(item, ptr)
+	return cfg.GitHub.Host
 }
 
-func LoadKeyringItem(item string, ptr *string) (err error) {
-	service := "bub"
-	if pw, err := keyring.Get(service, item); err == nil {
-		*ptr = pw
-		return nil
-	} else if err == keyring.ErrNotFound {
-		return setKeyringItem(item, ptr)
-	} else {
-		return err
-	}
+// GitHubURL returns the URL of a page of GitHub, e.g. GitHubURL("benchlabs", "bub") for the repository.
+func (cfg *Configuration) GitHubURL(elements ...string) string {
+	return "https://" + path.Join(append([]string{cfg.GitHubHost()}, elements...)...)
 }
 
-func setKeyringItem(item string, ptr *string) (err error) {
-	service := "bub"
-	prompt := promptui.Prompt{
-		Label: "Enter " + item,
-	}
-	if strings.HasSuffix(strings.ToLower(item), "password") {
-		prompt.Mask = '*'
-	}
-	result, err := prompt.Run()
-	if err != nil {
-		return err
-	}
-	err = keyring.Set(service, item, string(result))
-	if err != nil {
-		return err
+// GitHubAPIURL returns the base URL of the GitHub API, https://<host>/api/v3 for GitHub Enterprise.
+func (cfg *Configuration) GitHubAPIURL() string {
+	if cfg.GitHubHost() == "github.com" {
+		return "https://api.github.com"
 	}
-	return LoadKeyringItem(item, ptr)
+	return cfg.GitHubURL("api", "v3")
 }
 
-func equalAndNotEmpty(a, b string) bool {
-	return a != "" && a == b
+// CloneURL returns the URL of a repository of the organization for the configured protocol.
+func (cfg *Configuration) CloneURL(repository string) string {
+	if strings.EqualFold(cfg.GitHub.CloneProtocol, "https") {
+		return fmt.Sprintf("https://%v/%v/%v.git", cfg.GitHubHost(), cfg.GitHub.Organization, repository)
+	}
+	return fmt.Sprintf("git@%v:%v/%v.git", cfg.GitHubHost(), cfg.GitHub.Organization, repository)
 }
 
 func (cfg *Configuration) PopulateUser(u *User) error {
 	for _, userCfg := range cfg.Users {
 		if equalAndNotEmpty(u.GitHub, userCfg.GitHub) ||
 			equalAndNotEmpty(u.Name, userCfg.Name) ||
-			equalAndNotEmpty(u.Slack, userCfg.Slack) {
+			equalAndNotEmpty(u.Slack, userCfg.Slack) ||
+			(u.Email != "" && strings.EqualFold(u.Email, userCfg.Email)) {
 			return mergo.Merge(u, userCfg)
 		}
 	}
@@ -0,0 +1,127 @@
+package core
+
+import (
+	"fmt"
+	"github.com/j-martin/bub/utils"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+)
+
+// findConfigField returns the field matching the path, e.g. jira.board
+func findConfigField(cfg *Configuration, fieldPath string) (configField, error) {
+	for _, f := range listConfigFields(cfg) {
+		if f.Path == fieldPath {
+			return f, nil
+		}
+	}
+	return configField{}, fmt.Errorf("unknown config path '%v', see 'bub config --preview --sources'", fieldPath)
+}
+
+// GetConfigValue returns the effective value at the path, the secrets are redacted.
+func GetConfigValue(cfg *Configuration, fieldPath string) (string, error) {
+	f, err := findConfigField(cfg, fieldPath)
+	if err != nil {
+		return "", err
+	}
+	return f.String(), nil
+}
+
+// SetConfigValue writes a single value to the config file, see SetConfigValues.
+func SetConfigValue(configFile, fieldPath, value string) error {
+	return SetConfigValues(configFile, map[string]string{fieldPath: value})
+}
+
+// valueNode returns the node to be written for the value, the value is validated against the field type.
+func valueNode(fieldPath, value string) (*yaml.Node, error) {
+	f, err := findConfigField(&Configuration{}, fieldPath)
+	if err != nil {
+		return nil, err
+	}
+	if err = f.set(value); err != nil {
+		return nil, err
+	}
+	if f.Value.Kind() != reflect.Slice {
+		n := &yaml.Node{}
+		return n, n.Encode(f.Value.Interface())
+	}
+	n := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	for _, i := range f.Value.Interface().([]string) {
+		n.Content = append(n.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: i})
+	}
+	return n, nil
+}
+
+// setNodeValue replaces or adds the value at the path, the comments of the existing value are kept.
+func setNodeValue(root *yaml.Node, keys []string, value *yaml.Node) {
+	n := root
+	for i, key := range keys {
+		var existing *yaml.Node
+		for j := 0; j+1 < len(n.Content); j += 2 {
+			if n.Content[j].Value == key {
+				existing = n.Content[j+1]
+			}
+		}
+		last := i == len(keys)-1
+		if existing == nil {
+			existing = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			if last {
+				existing = value
+			}
+			n.Content = append(n.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, existing)
+		} else if last {
+			value.HeadComment, value.LineComment, value.FootComment = existing.HeadComment, existing.LineComment, existing.FootComment
+			*existing = *value
+		} else if existing.Kind != yaml.MappingNode {
+			*existing = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", LineComment: existing.LineComment}
+		}
+		n = existing
+	}
+}
+
+// SetConfigValues writes the values to the config file, e.g. {"jira.board": "42"}.
+// The comments and the order of the keys are preserved.
+func SetConfigValues(configFile string, values map[string]string) error {
+	return setConfigValues(GetConfigPath(configFile), values)
+}
+
+func setConfigValues(configPath string, values map[string]string) error {
+	data, err := ioutil.ReadFile(configPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	doc := &yaml.Node{}
+	if err = yaml.Unmarshal(data, doc); err != nil {
+		return fmt.Errorf("failed to parse %v: %v", configPath, err)
+	}
+	if len(doc.Content) == 0 {
+		doc = &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+		setConfigVersion(doc.Content[0], ConfigVersion)
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("%v must contain a mapping of the config sections", configPath)
+	}
+	for _, fieldPath := range utils.SortedKeys(values) {
+		if isSecretField(fieldPath) {
+			return fmt.Errorf("%v is a secret, it is stored by the credentials backend, see 'bub credentials'", fieldPath)
+		}
+		n, err := valueNode(fieldPath, values[fieldPath])
+		if err != nil {
+			return err
+		}
+		setNodeValue(root, strings.Split(fieldPath, "."), n)
+	}
+
+	updated, err := encodeYAMLDocument(doc)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(path.Dir(configPath), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(configPath, updated, 0600)
+}
@@ -0,0 +1,57 @@
+package core
+
+import (
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestSetConfigValues(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bub")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	configPath := path.Join(dir, ConfigUserFile)
+	assert.NoError(t, ioutil.WriteFile(configPath, []byte(GetConfigString()), 0600))
+
+	assert.NoError(t, setConfigValues(configPath, map[string]string{
+		"jira.enabled":     "true",
+		"jira.project":     "OPS",
+		"github.reviewers": "octocat,doctocat",
+		"git.noVerify":     "true",
+	}))
+	data, err := ioutil.ReadFile(configPath)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "project: OPS # default project to use when creating issues.")
+	assert.Contains(t, string(data), "# use 'bub config --shared' to edit the shared config.")
+
+	cfg := &Configuration{}
+	assert.NoError(t, yaml.Unmarshal(data, cfg))
+	assert.True(t, *cfg.JIRA.Enabled)
+	assert.True(t, cfg.Git.NoVerify)
+	assert.Equal(t, []string{"octocat", "doctocat"}, cfg.GitHub.Reviewers)
+	assert.Equal(t, "https://example.atlassian.net", cfg.JIRA.Server)
+
+	assert.Error(t, setConfigValues(configPath, map[string]string{"jira.unknown": "true"}))
+	assert.Error(t, setConfigValues(configPath, map[string]string{"ssh.connectTimeout": "soon"}))
+	assert.Error(t, setConfigValues(configPath, map[string]string{"jira.password": "hunter2"}))
+}
+
+func TestGetConfigValue(t *testing.T) {
+	cfg := &Configuration{}
+	cfg.JIRA.Project = "OPS"
+	cfg.GitHub.Token = "secret"
+	cfg.GitHub.Reviewers = []string{"octocat", "doctocat"}
+
+	value, err := GetConfigValue(cfg, "jira.project")
+	assert.NoError(t, err)
+	assert.Equal(t, "OPS", value)
+	value, _ = GetConfigValue(cfg, "github.reviewers")
+	assert.Equal(t, "octocat,doctocat", value)
+	value, _ = GetConfigValue(cfg, "github.token")
+	assert.Equal(t, redactedValue, value)
+	_, err = GetConfigValue(cfg, "jira")
+	assert.Error(t, err)
+}
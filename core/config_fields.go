@@ -0,0 +1,240 @@
+package core
+
+import (
+	"fmt"
+	"github.com/imdario/mergo"
+	"github.com/j-martin/bub/utils"
+	"gopkg.in/yaml.v3"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	SourceDefault     = "default"
+	SourceEnvironment = "environment"
+	SourceKeyring     = "keyring"
+	SourceCommand     = "command"
+	SourceFile        = "file"
+
+	redactedValue = "<redacted>"
+)
+
+type configLayer struct {
+	source string
+	cfg    *Configuration
+}
+
+// configField is a configuration value addressable by its path. e.g. jira.server
+type configField struct {
+	Path  string
+	Value reflect.Value
+}
+
+// listConfigFields lists the values of every section, e.g. jira.server, and the top level lists, e.g. users.
+func listConfigFields(cfg *Configuration) (fields []configField) {
+	return appendConfigFields(fields, "", reflect.ValueOf(cfg).Elem())
+}
+
+func appendConfigFields(fields []configField, prefix string, v reflect.Value) []configField {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" || f.Tag.Get("yaml") == "-" {
+			continue
+		}
+		fieldPath := joinPath(prefix, yamlKey(f))
+		if f.Type.Kind() == reflect.Struct {
+			fields = appendConfigFields(fields, fieldPath, v.Field(i))
+			continue
+		}
+		if prefix == "" && f.Type.Kind() != reflect.Slice {
+			// runtime options like ResetCredentials.
+			continue
+		}
+		fields = append(fields, configField{Path: fieldPath, Value: v.Field(i)})
+	}
+	return fields
+}
+
+// envName returns the variable overriding the value, e.g. jira.server -> BUB_JIRA_SERVER
+func (f configField) envName() string {
+	return "BUB_" + strings.ToUpper(strings.Replace(f.Path, ".", "_", -1))
+}
+
+// set parses and assigns the value, only scalars and lists of strings are supported.
+func (f configField) set(value string) error {
+	switch f.Value.Kind() {
+	case reflect.String:
+		f.Value.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%v must be true or false: %v", f.Path, err)
+		}
+		f.Value.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%v must be a number: %v", f.Path, err)
+		}
+		f.Value.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%v must be a positive number: %v", f.Path, err)
+		}
+		f.Value.SetUint(i)
+	case reflect.Ptr:
+		// optional values, e.g. jira.enabled
+		v := reflect.New(f.Value.Type().Elem())
+		if err := (configField{Path: f.Path, Value: v.Elem()}).set(value); err != nil {
+			return err
+		}
+		f.Value.Set(v)
+	case reflect.Slice:
+		if f.Value.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("%v cannot be set from a string", f.Path)
+		}
+		f.Value.Set(reflect.ValueOf(utils.SplitAndTrim(value, ",")))
+	default:
+		return fmt.Errorf("%v cannot be set from a string", f.Path)
+	}
+	return nil
+}
+
+func (f configField) isZero() bool {
+	if f.Value.Kind() == reflect.Slice || f.Value.Kind() == reflect.Map {
+		return f.Value.Len() == 0
+	}
+	return reflect.DeepEqual(f.Value.Interface(), reflect.Zero(f.Value.Type()).Interface())
+}
+
+func (f configField) String() string {
+	if f.Value.Kind() == reflect.Ptr {
+		if f.Value.IsNil() {
+			return ""
+		}
+		return configField{Path: f.Path, Value: f.Value.Elem()}.String()
+	}
+	if f.Value.Kind() == reflect.Map {
+		return fmt.Sprintf("<%v items>", f.Value.Len())
+	}
+	if f.Value.Kind() == reflect.Slice {
+		if f.Value.Type().Elem().Kind() == reflect.String {
+			return strings.Join(f.Value.Interface().([]string), ",")
+		}
+		return fmt.Sprintf("<%v items>", f.Value.Len())
+	}
+	if isSecretField(f.Path) && !f.isZero() {
+		return redactedValue
+	}
+	return fmt.Sprint(f.Value.Interface())
+}
+
+func isSecretField(fieldPath string) bool {
+	key := fieldPath[strings.LastIndex(fieldPath, ".")+1:]
+	return key == "password" || key == "token"
+}
+
+// resolveSources returns where each value comes from, the layers are ordered by precedence.
+func resolveSources(cfg *Configuration, layers []configLayer) map[string]string {
+	sources := map[string]string{}
+	for _, f := range listConfigFields(cfg) {
+		sources[f.Path] = SourceDefault
+	}
+	for i := len(layers) - 1; i >= 0; i-- {
+		for _, f := range listConfigFields(layers[i].cfg) {
+			if !f.isZero() {
+				sources[f.Path] = layers[i].source
+			}
+		}
+	}
+	return sources
+}
+
+// mergeProfileConfiguration applies the profile on top of the config. The values set in the profile
+// override the config, the empty ones too, e.g. 'noVerify: false' or 'reviewers: []'. A key without a
+// value, e.g. 'project:', is ignored. It returns the paths of the values set by the profile.
+func mergeProfileConfiguration(cfg, profileCfg *Configuration, profileData []byte) ([]string, error) {
+	if err := mergo.MergeWithOverwrite(cfg, *profileCfg); err != nil {
+		return nil, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(profileData, &doc); err != nil || len(doc.Content) == 0 {
+		return nil, err
+	}
+	profileFields := map[string]configField{}
+	for _, f := range listConfigFields(profileCfg) {
+		profileFields[f.Path] = f
+	}
+	var paths []string
+	for _, f := range listConfigFields(cfg) {
+		n := findNode(doc.Content[0], strings.Split(f.Path, ".")...)
+		if n == nil || n.Tag == "!!null" {
+			continue
+		}
+		// the other values were merged above, maps included.
+		if profileFields[f.Path].isZero() {
+			f.Value.Set(profileFields[f.Path].Value)
+		}
+		paths = append(paths, f.Path)
+	}
+	return paths, nil
+}
+
+// applyEnvironment overrides the values with the BUB_<SECTION>_<FIELD> variables. e.g. BUB_JIRA_PROJECT
+func (cfg *Configuration) applyEnvironment() error {
+	for _, f := range listConfigFields(cfg) {
+		value, ok := os.LookupEnv(f.envName())
+		if !ok {
+			continue
+		}
+		if err := f.set(value); err != nil {
+			return fmt.Errorf("invalid value for %v: %v", f.envName(), err)
+		}
+		cfg.setSource(f.Path, SourceEnvironment)
+	}
+	return nil
+}
+
+func (cfg *Configuration) setSource(fieldPath, source string) {
+	if cfg.Sources == nil {
+		cfg.Sources = map[string]string{}
+	}
+	cfg.Sources[fieldPath] = source
+}
+
+// ShowConfigSources lists every value with its source, the secrets are redacted.
+// The credentials that were not loaded yet are looked up in their backend, without prompting.
+func ShowConfigSources(cfg *Configuration) error {
+	credentials := map[string]string{}
+	for _, i := range cfg.CredentialItems() {
+		credentials[i.Path] = i.Name
+	}
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "Path\tValue\tSource")
+	fields := listConfigFields(cfg)
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].Path < fields[j].Path
+	})
+	for _, f := range fields {
+		value := f.String()
+		source := cfg.Sources[f.Path]
+		if source == "" {
+			source = SourceDefault
+		}
+		if item, ok := credentials[f.Path]; ok && f.isZero() {
+			if backend, err := cfg.credentialBackend(item); err == nil {
+				if _, err := backend.Get(item); err == nil {
+					value, source = redactedValue, backend.Name()
+				}
+			}
+		}
+		fmt.Fprintln(table, strings.Join([]string{f.Path, value, source}, "\t"))
+	}
+	return table.Flush()
+}
@@ -0,0 +1,83 @@
+package core
+
+import (
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+	"os"
+	"testing"
+)
+
+func TestApplyEnvironment(t *testing.T) {
+	os.Setenv("BUB_JIRA_PROJECT", "PL")
+	os.Setenv("BUB_GIT_NOVERIFY", "true")
+	os.Setenv("BUB_GITHUB_REVIEWERS", "octocat, doctocat")
+	defer os.Unsetenv("BUB_JIRA_PROJECT")
+	defer os.Unsetenv("BUB_GIT_NOVERIFY")
+	defer os.Unsetenv("BUB_GITHUB_REVIEWERS")
+
+	cfg := &Configuration{}
+	cfg.JIRA.Project = "OPS"
+	assert.NoError(t, cfg.applyEnvironment())
+	assert.Equal(t, "PL", cfg.JIRA.Project)
+	assert.True(t, cfg.Git.NoVerify)
+	assert.Equal(t, []string{"octocat", "doctocat"}, cfg.GitHub.Reviewers)
+	assert.Equal(t, SourceEnvironment, cfg.Sources["jira.project"])
+
+	os.Setenv("BUB_SSH_CONNECTTIMEOUT", "soon")
+	defer os.Unsetenv("BUB_SSH_CONNECTTIMEOUT")
+	assert.Error(t, cfg.applyEnvironment())
+}
+
+func TestResolveSources(t *testing.T) {
+	t.Parallel()
+	shared, user, cfg := &Configuration{}, &Configuration{}, &Configuration{}
+	shared.JIRA.Server = "https://shared.atlassian.net"
+	user.JIRA.Server = "https://user.atlassian.net"
+	user.JIRA.Project = "PL"
+	sources := resolveSources(cfg, []configLayer{{ConfigSharedFile, shared}, {ConfigUserFile, user}})
+	assert.Equal(t, ConfigSharedFile, sources["jira.server"])
+	assert.Equal(t, ConfigUserFile, sources["jira.project"])
+	assert.Equal(t, SourceDefault, sources["jira.board"])
+}
+
+func TestMergeProfileConfiguration(t *testing.T) {
+	t.Parallel()
+	cfg := &Configuration{}
+	cfg.Git.NoVerify = true
+	cfg.GitHub.Reviewers = []string{"octocat"}
+	cfg.JIRA.Project = "OPS"
+	cfg.JIRA.Server = "https://user.atlassian.net"
+	data := []byte(`---
+git:
+  noVerify: false
+github:
+  reviewers: []
+jira:
+  project:
+  server: https://work.atlassian.net
+`)
+	profileCfg := &Configuration{}
+	assert.NoError(t, yaml.Unmarshal(data, profileCfg))
+	paths, err := mergeProfileConfiguration(cfg, profileCfg, data)
+	assert.NoError(t, err)
+	assert.False(t, cfg.Git.NoVerify)
+	assert.Empty(t, cfg.GitHub.Reviewers)
+	assert.Equal(t, "OPS", cfg.JIRA.Project)
+	assert.Equal(t, "https://work.atlassian.net", cfg.JIRA.Server)
+	assert.Equal(t, []string{"git.noVerify", "github.reviewers", "jira.server"}, paths)
+}
+
+func TestConfigFieldRedaction(t *testing.T) {
+	t.Parallel()
+	cfg := &Configuration{}
+	cfg.GitHub.Token = "secret"
+	cfg.GitHub.Organization = "benchlabs"
+	for _, f := range listConfigFields(cfg) {
+		switch f.Path {
+		case "github.token":
+			assert.Equal(t, redactedValue, f.String())
+		case "github.organization":
+			assert.Equal(t, "benchlabs", f.String())
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/j-martin/bub/utils"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"log"
+	"path"
+	"path/filepath"
+	"strconv"
+)
+
+// ConfigVersion is the version of the config format, bump it when adding a migration.
+const ConfigVersion = 2
+
+type configMigration struct {
+	// version of the files once migrated.
+	Version     int
+	Description string
+	Migrate     func(root *yaml.Node) error
+}
+
+// configMigrations are applied in order to the files older than their version.
+var configMigrations = []configMigration{
+	{
+		Version:     2,
+		Description: "clear the jira.board placeholder of the version 1 template",
+		Migrate: func(root *yaml.Node) error {
+			board := findNode(root, "jira", "board")
+			if board != nil && board.Value == "id of the board when creating issues in the current sprint." {
+				*board = yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", LineComment: "# " + board.Value}
+			}
+			return nil
+		},
+	},
+}
+
+// mappingKeyIndex returns the index of the key in the mapping content, or -1 if absent.
+func mappingKeyIndex(n *yaml.Node, key string) int {
+	if n == nil || n.Kind != yaml.MappingNode {
+		return -1
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// configVersion returns the version of the document, the files without version are version 1.
+func configVersion(root *yaml.Node) (int, error) {
+	n := findNode(root, "version")
+	if isEmptyNode(n) {
+		return 1, nil
+	}
+	version, err := strconv.Atoi(n.Value)
+	if err != nil {
+		return 0, fmt.Errorf("line %v: version must be a number, got '%v'", n.Line, n.Value)
+	}
+	return version, nil
+}
+
+func setConfigVersion(root *yaml.Node, version int) {
+	value := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(version)}
+	if i := mappingKeyIndex(root, "version"); i >= 0 {
+		root.Content[i+1] = value
+		return
+	}
+	key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "version"}
+	root.Content = append([]*yaml.Node{key, value}, root.Content...)
+}
+
+// migrateConfigurationData applies the pending migrations to the document.
+// It returns the version of the document before the migrations.
+func migrateConfigurationData(data []byte) ([]byte, int, error) {
+	doc := &yaml.Node{}
+	if err := yaml.Unmarshal(data, doc); err != nil {
+		return nil, 0, err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return data, ConfigVersion, nil
+	}
+	root := doc.Content[0]
+	version, err := configVersion(root)
+	if err != nil || version >= ConfigVersion {
+		return data, version, err
+	}
+	for _, m := range configMigrations {
+		if m.Version <= version {
+			continue
+		}
+		if err = m.Migrate(root); err != nil {
+			return nil, version, fmt.Errorf("failed to migrate to version %v (%v): %v", m.Version, m.Description, err)
+		}
+	}
+	setConfigVersion(root, ConfigVersion)
+	migrated, err := encodeYAMLDocument(doc)
+	return migrated, version, err
+}
+
+func encodeYAMLDocument(doc *yaml.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return append([]byte("---\n"), buf.Bytes()...), nil
+}
+
+func warnOutdatedConfiguration(configPath string, version int) {
+	if version < ConfigVersion {
+		log.Printf("%v uses the config format version %v, run 'bub config migrate' to update it to version %v.",
+			configPath, version, ConfigVersion)
+	} else if version > ConfigVersion {
+		log.Printf("%v uses the config format version %v, which is newer than this version of bub (%v). Update bub.",
+			configPath, version, ConfigVersion)
+	}
+}
+
+// backupConfigFile copies the file next to itself, e.g. config.yml.20180102-150405.bak
+func backupConfigFile(configPath string) (string, error) {
+	backup := configPath + "." + utils.CurrentTimeForFilename() + ".bak"
+	return backup, utils.Copy(configPath, backup)
+}
+
+// MigrateConfiguration rewrites the outdated shared, user and profile config files to the current format.
+// The original files are backed up.
+func MigrateConfiguration() error {
+	configPaths := []string{GetConfigPath(ConfigSharedFile), GetConfigPath(ConfigUserFile)}
+	profiles, err := filepath.Glob(path.Join(GetConfigPath(ConfigProfilesDir), "*.yml"))
+	if err != nil {
+		return err
+	}
+	configPaths = append(configPaths, profiles...)
+
+	migrated := 0
+	for _, configPath := range configPaths {
+		exists, err := utils.PathExists(configPath)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			continue
+		}
+		data, err := ioutil.ReadFile(configPath)
+		if err != nil {
+			return err
+		}
+		updated, version, err := migrateConfigurationData(data)
+		if err != nil {
+			return fmt.Errorf("%v: %v", configPath, err)
+		}
+		if version > ConfigVersion {
+			warnOutdatedConfiguration(configPath, version)
+			continue
+		}
+		if version == ConfigVersion {
+			continue
+		}
+		backup, err := backupConfigFile(configPath)
+		if err != nil {
+			return err
+		}
+		for _, m := range configMigrations {
+			if m.Version > version {
+				log.Printf("%v: version %v, %v.", configPath, m.Version, m.Description)
+			}
+		}
+		if err = ioutil.WriteFile(configPath, updated, 0600); err != nil {
+			return err
+		}
+		log.Printf("Migrated %v to version %v, the original file is backed up to %v", configPath, ConfigVersion, backup)
+		migrated++
+	}
+	if migrated == 0 {
+		log.Printf("The config files are already up to date (version %v).", ConfigVersion)
+	}
+	return nil
+}
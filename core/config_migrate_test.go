@@ -0,0 +1,33 @@
+package core
+
+import (
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+	"testing"
+)
+
+func TestMigrateConfigurationData(t *testing.T) {
+	data := []byte(`---
+# bub config
+vault:
+  authmethod: LDAP # how to login
+jira:
+  board: id of the board when creating issues in the current sprint.
+`)
+	migrated, version, err := migrateConfigurationData(data)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, version)
+	assert.Contains(t, string(migrated), "authmethod: LDAP # how to login")
+	assert.Contains(t, string(migrated), "# bub config")
+
+	cfg := &Configuration{}
+	assert.NoError(t, yaml.Unmarshal(migrated, cfg))
+	assert.Equal(t, ConfigVersion, cfg.Version)
+	assert.Equal(t, "LDAP", cfg.Vault.AuthMethod)
+	assert.Empty(t, cfg.JIRA.Board)
+
+	unchanged, version, err := migrateConfigurationData(migrated)
+	assert.NoError(t, err)
+	assert.Equal(t, ConfigVersion, version)
+	assert.Equal(t, migrated, unchanged)
+}
@@ -0,0 +1,130 @@
+package core
+
+import (
+	"fmt"
+	"github.com/j-martin/bub/utils"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"log"
+	"path"
+	"strings"
+)
+
+const ConfigRepositoryFile = ".bub.yml"
+
+// repositoryConfigFields are the only values a repository can set in its .bub.yml,
+// the other values (e.g. servers) could be used to leak credentials.
+var repositoryConfigFields = []string{
+	"git.noVerify",
+	"git.baseBranch",
+	"github.reviewers",
+	"jira.project",
+	"jira.board",
+	"jira.transitions",
+}
+
+func getRepositoryConfigPath(repoDir string) (string, error) {
+	root, err := MustInitGit(repoDir).GetRepositoryRootPath()
+	if err != nil {
+		return "", err
+	}
+	return path.Join(root, ConfigRepositoryFile), nil
+}
+
+// readRepositoryBaseBranch returns the git.baseBranch of the repository's .bub.yml, empty if not set.
+func readRepositoryBaseBranch(repoDir string) (string, error) {
+	configPath, err := getRepositoryConfigPath(repoDir)
+	if err != nil {
+		return "", err
+	}
+	exists, err := utils.PathExists(configPath)
+	if err != nil || !exists {
+		return "", err
+	}
+	data, err := ioutil.ReadFile(configPath)
+	if err != nil {
+		return "", err
+	}
+	repoCfg := &Configuration{}
+	err = yaml.Unmarshal(data, repoCfg)
+	return repoCfg.Git.BaseBranch, err
+}
+
+// listNodePaths lists the paths defined in the document, e.g. jira.project
+func listNodePaths(root *yaml.Node) (keys []*yaml.Node, names []string) {
+	if root == nil || root.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		section, value := root.Content[i], root.Content[i+1]
+		if value.Kind != yaml.MappingNode {
+			keys, names = append(keys, section), append(names, section.Value)
+			continue
+		}
+		for j := 0; j+1 < len(value.Content); j += 2 {
+			keys, names = append(keys, value.Content[j]), append(names, section.Value+"."+value.Content[j].Value)
+		}
+	}
+	return keys, names
+}
+
+// checkRepositoryConfigFields reports the values that cannot be set by a repository.
+func checkRepositoryConfigFields(file string, root *yaml.Node) (errs ValidationErrors) {
+	nodes, names := listNodePaths(root)
+	for i, name := range names {
+		if !utils.Contains(name, repositoryConfigFields...) {
+			errs = append(errs, ValidationError{
+				File: file,
+				Line: nodes[i].Line,
+				Message: fmt.Sprintf("'%v' cannot be set per repository, only %v are allowed",
+					name, strings.Join(repositoryConfigFields, ", ")),
+			})
+		}
+	}
+	return errs
+}
+
+// applyRepositoryConfiguration merges the allowed values of the repository's .bub.yml on top of the config.
+// A malformed .bub.yml is ignored with a warning, it must not prevent using bub in the repository.
+func (cfg *Configuration) applyRepositoryConfiguration(repoDir string) error {
+	configPath, err := getRepositoryConfigPath(repoDir)
+	if err != nil {
+		return err
+	}
+	exists, err := utils.PathExists(configPath)
+	if err != nil || !exists {
+		return err
+	}
+	data, err := ioutil.ReadFile(configPath)
+	if err != nil {
+		return err
+	}
+	root, errs := parseYAMLDocument(configPath, data)
+	if len(errs) > 0 {
+		log.Printf("Ignoring %v: %v", configPath, errs[0])
+		return nil
+	}
+	if root == nil {
+		return nil
+	}
+	for _, e := range checkRepositoryConfigFields(configPath, root) {
+		log.Printf("Ignoring: %v", e)
+	}
+	repoCfg := &Configuration{}
+	if err = root.Decode(repoCfg); err != nil {
+		log.Printf("Ignoring %v: %v", configPath, err)
+		return nil
+	}
+	repoFields := map[string]configField{}
+	for _, f := range listConfigFields(repoCfg) {
+		repoFields[f.Path] = f
+	}
+	for _, f := range listConfigFields(cfg) {
+		if !utils.Contains(f.Path, repositoryConfigFields...) || findNode(root, strings.Split(f.Path, ".")...) == nil {
+			continue
+		}
+		f.Value.Set(repoFields[f.Path].Value)
+		cfg.setSource(f.Path, ConfigRepositoryFile)
+	}
+	return nil
+}
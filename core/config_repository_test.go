@@ -0,0 +1,32 @@
+package core
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestApplyRepositoryConfiguration(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bub")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	assert.NoError(t, MustInitGit(dir).RunGit("init", "-q"))
+	configPath := path.Join(dir, ConfigRepositoryFile)
+
+	assert.NoError(t, ioutil.WriteFile(configPath, []byte("jira:\n  project: PAY\njenkins:\n  server: https://evil.example.com\n"), 0600))
+	cfg := &Configuration{}
+	cfg.JIRA.Project = "OPS"
+	assert.NoError(t, cfg.applyRepositoryConfiguration(dir))
+	assert.Equal(t, "PAY", cfg.JIRA.Project)
+	assert.Equal(t, ConfigRepositoryFile, cfg.Sources["jira.project"])
+	assert.Empty(t, cfg.Jenkins.Server)
+
+	// a malformed .bub.yml is ignored.
+	assert.NoError(t, ioutil.WriteFile(configPath, []byte("jira:\n  project: [PAY]\n"), 0600))
+	cfg = &Configuration{}
+	cfg.JIRA.Project = "OPS"
+	assert.NoError(t, cfg.applyRepositoryConfiguration(dir))
+	assert.Equal(t, "OPS", cfg.JIRA.Project)
+}
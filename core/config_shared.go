@@ -0,0 +1,157 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/j-martin/bub/utils"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+)
+
+func (cfg *Configuration) sharedConfigPath() string {
+	if cfg.SharedConfig.Path == "" {
+		return ConfigSharedFile
+	}
+	return cfg.SharedConfig.Path
+}
+
+// fetchSharedConfigSource returns the directory containing the published shared config.
+// Git repositories are cloned to a temporary directory, the caller must remove it.
+func (cfg *Configuration) fetchSharedConfigSource() (dir string, isTemp bool, err error) {
+	source := cfg.SharedConfig.Source
+	if source == "" {
+		return "", false, errors.New("no shared config source defined, set 'sharedConfig.source' with 'bub config'")
+	}
+	isDir, _ := utils.PathExists(source)
+	if isDir {
+		return source, false, nil
+	}
+	dir, err = ioutil.TempDir("", "bub-shared-config")
+	if err != nil {
+		return "", false, err
+	}
+	log.Printf("Cloning: %v", source)
+	output, err := utils.RunCmdWithFullOutput("git", "clone", "--depth", "1", source, dir)
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", false, fmt.Errorf("failed to clone the shared config: %v\n%v", err, output)
+	}
+	return dir, true, nil
+}
+
+// showFileDiff prints the difference between the current and the new file.
+// Returns false if there is no difference.
+func showFileDiff(currentFile, newFile string) (bool, error) {
+	current, err := ioutil.ReadFile(currentFile)
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	updated, err := ioutil.ReadFile(newFile)
+	if err != nil {
+		return false, err
+	}
+	if bytes.Equal(current, updated) {
+		return false, nil
+	}
+	if len(current) == 0 {
+		fmt.Println(string(updated))
+		return true, nil
+	}
+	// git returns a non-zero exit code when the files differ.
+	utils.RunCmd("git", "--no-pager", "diff", "--no-index", currentFile, newFile)
+	return true, nil
+}
+
+func LoadSharedConfig(cfg *Configuration) error {
+	dir, isTemp, err := cfg.fetchSharedConfigSource()
+	if err != nil {
+		return err
+	}
+	if isTemp {
+		defer os.RemoveAll(dir)
+	}
+	source := path.Join(dir, cfg.sharedConfigPath())
+	data, err := ioutil.ReadFile(source)
+	if err != nil {
+		return err
+	}
+	if err = yaml.Unmarshal(data, &Configuration{}); err != nil {
+		return fmt.Errorf("the published shared config is invalid: %v", err)
+	}
+
+	target := GetConfigPath(ConfigSharedFile)
+	changed, err := showFileDiff(target, source)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		log.Print("The shared config is already up to date.")
+		return nil
+	}
+	if !utils.AskForConfirmation("Write the changes to " + target + "?") {
+		return nil
+	}
+	exists, err := utils.PathExists(target)
+	if err != nil {
+		return err
+	}
+	if exists {
+		backup, err := backupConfigFile(target)
+		if err != nil {
+			return err
+		}
+		log.Printf("Backed up the current shared config to %v", backup)
+	}
+	if err = os.MkdirAll(path.Dir(target), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(target, data, 0600)
+}
+
+func StoreSharedConfig(cfg *Configuration) error {
+	dir, isTemp, err := cfg.fetchSharedConfigSource()
+	if err != nil {
+		return err
+	}
+	if isTemp {
+		defer os.RemoveAll(dir)
+	}
+	source := GetConfigPath(ConfigSharedFile)
+	target := path.Join(dir, cfg.sharedConfigPath())
+	changed, err := showFileDiff(target, source)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		log.Print("The published shared config is already up to date.")
+		return nil
+	}
+	if !utils.AskForConfirmation("Publish the changes to " + cfg.SharedConfig.Source + "?") {
+		return nil
+	}
+	if err = os.MkdirAll(path.Dir(target), 0700); err != nil {
+		return err
+	}
+	if err = utils.Copy(source, target); err != nil {
+		return err
+	}
+	if !utils.IsRepository(dir) {
+		return nil
+	}
+	g := MustInitGit(dir)
+	if err = g.RunGit("add", cfg.sharedConfigPath()); err != nil {
+		return err
+	}
+	if err = g.RunGit("commit", "-m", "Update shared bub config"); err != nil {
+		return err
+	}
+	if !isTemp {
+		log.Printf("Committed in %v, push the changes to share them.", dir)
+		return nil
+	}
+	return g.RunGit("push", "origin", "HEAD")
+}
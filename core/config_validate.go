@@ -0,0 +1,161 @@
+package core
+
+import (
+	"fmt"
+	"github.com/j-martin/bub/utils"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ValidateConfiguration validates the shared, user and profile config files,
+// and the .bub.yml of the current repository.
+func ValidateConfiguration(profile string) (ValidationErrors, error) {
+	files := []string{ConfigSharedFile, ConfigUserFile}
+	if profile != "" {
+		files = append(files, GetProfileConfigFile(profile))
+	}
+	var configPaths []string
+	for _, f := range files {
+		configPaths = append(configPaths, GetConfigPath(f))
+	}
+	repoConfigPath := ""
+	if utils.InRepository() {
+		var err error
+		if repoConfigPath, err = getRepositoryConfigPath("."); err != nil {
+			return nil, err
+		}
+		configPaths = append(configPaths, repoConfigPath)
+	}
+	var errs ValidationErrors
+	for _, configPath := range configPaths {
+		exists, err := utils.PathExists(configPath)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			continue
+		}
+		data, err := ioutil.ReadFile(configPath)
+		if err != nil {
+			return nil, err
+		}
+		errs = append(errs, validateConfigurationData(configPath, data)...)
+		if configPath != repoConfigPath {
+			errs = append(errs, checkConfigVersion(configPath, data)...)
+		} else {
+			root, _ := parseYAMLDocument(configPath, data)
+			errs = append(errs, checkRepositoryConfigFields(configPath, root)...)
+		}
+	}
+	sort.Stable(errs)
+	return errs, nil
+}
+
+func validateConfigurationData(file string, data []byte) ValidationErrors {
+	root, errs := parseYAMLDocument(file, data)
+	if root == nil {
+		return errs
+	}
+	if err := root.Decode(&Configuration{}); err != nil {
+		errs = append(errs, yamlErrors(file, err)...)
+	}
+	errs = append(errs, checkUnknownKeys(file, "", root, reflect.TypeOf(Configuration{}))...)
+	errs = append(errs, checkServerURLs(file, root)...)
+
+	board := findNode(root, "jira", "board")
+	if !isEmptyNode(board) {
+		if _, err := strconv.Atoi(board.Value); err != nil {
+			errs = append(errs, ValidationError{
+				File:    file,
+				Line:    board.Line,
+				Message: fmt.Sprintf("jira.board must be the numeric id of the board, got '%v'", board.Value),
+			})
+		}
+	}
+
+	protocol := findNode(root, "github", "cloneProtocol")
+	if !isEmptyNode(protocol) && !utils.Contains(strings.ToLower(protocol.Value), "ssh", "https") {
+		errs = append(errs, ValidationError{
+			File:    file,
+			Line:    protocol.Line,
+			Message: fmt.Sprintf("github.cloneProtocol must be ssh or https, got '%v'", protocol.Value),
+		})
+	}
+
+	transitions := findNode(root, "jira", "transitions")
+	if transitions != nil && transitions.Kind == yaml.SequenceNode {
+		aliases := map[string]int{}
+		for _, tr := range transitions.Content {
+			alias := findNode(tr, "alias")
+			if isEmptyNode(alias) {
+				continue
+			}
+			key := strings.ToLower(strings.TrimSpace(alias.Value))
+			if line, ok := aliases[key]; ok {
+				errs = append(errs, ValidationError{
+					File:    file,
+					Line:    alias.Line,
+					Message: fmt.Sprintf("the transition alias '%v' is already defined on line %v", alias.Value, line),
+				})
+				continue
+			}
+			aliases[key] = alias.Line
+		}
+	}
+	return errs
+}
+
+func checkConfigVersion(file string, data []byte) ValidationErrors {
+	root, _ := parseYAMLDocument(file, data)
+	if root == nil {
+		return nil
+	}
+	line := 1
+	if n := findNode(root, "version"); n != nil {
+		line = n.Line
+	}
+	version, err := configVersion(root)
+	if err != nil {
+		return nil
+	}
+	message := ""
+	if version < ConfigVersion {
+		message = fmt.Sprintf("the config format version %v is outdated, run 'bub config migrate' to update it to version %v", version, ConfigVersion)
+	} else if version > ConfigVersion {
+		message = fmt.Sprintf("the config format version %v is not supported by this version of bub (%v)", version, ConfigVersion)
+	} else {
+		return nil
+	}
+	return ValidationErrors{{File: file, Line: line, Message: message}}
+}
+
+// checkServerURLs validates every 'server' key of the config sections.
+func checkServerURLs(file string, root *yaml.Node) (errs ValidationErrors) {
+	if root.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		section := root.Content[i].Value
+		server := findNode(root.Content[i+1], "server")
+		if isEmptyNode(server) {
+			continue
+		}
+		u, err := url.Parse(server.Value)
+		if err == nil && (u.Scheme != "http" && u.Scheme != "https" || u.Host == "" || strings.Contains(u.Host, "..")) {
+			err = fmt.Errorf("expected an URL like https://example.com")
+		}
+		if err != nil {
+			errs = append(errs, ValidationError{
+				File:    file,
+				Line:    server.Line,
+				Message: fmt.Sprintf("%v.server '%v' is not a valid URL: %v", section, server.Value, err),
+			})
+		}
+	}
+	return errs
+}
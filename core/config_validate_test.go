@@ -0,0 +1,52 @@
+package core
+
+import (
+	"github.com/stretchr/testify/assert"
+	"sort"
+	"testing"
+)
+
+func TestValidateConfigurationData(t *testing.T) {
+	t.Parallel()
+	content := `---
+github:
+  organization: benchlabs
+  reviewer:
+    - someone
+  cloneProtocol: git
+jira:
+  server: example.atlassian.net
+  board: some board
+  transitions:
+    - name: In Progress
+      alias: progress
+    - name: Done
+      alias: Progress
+jenkins:
+  server: "https://jenkins.example.com"
+`
+	errs := validateConfigurationData("config.yml", []byte(content))
+	sort.Sort(errs)
+	assert.Equal(t, ValidationErrors{
+		{File: "config.yml", Line: 4, Message: "unknown key 'github.reviewer'"},
+		{File: "config.yml", Line: 6, Message: "github.cloneProtocol must be ssh or https, got 'git'"},
+		{File: "config.yml", Line: 8, Message: "jira.server 'example.atlassian.net' is not a valid URL: expected an URL like https://example.com"},
+		{File: "config.yml", Line: 9, Message: "jira.board must be the numeric id of the board, got 'some board'"},
+		{File: "config.yml", Line: 14, Message: "the transition alias 'Progress' is already defined on line 12"},
+	}, errs)
+}
+
+func TestValidateConfigurationDataTypeErrors(t *testing.T) {
+	t.Parallel()
+	errs := validateConfigurationData("shared.yml", []byte("ssh:\n  connectTimeout: soon\n"))
+	assert.Len(t, errs, 1)
+	assert.Equal(t, 2, errs[0].Line)
+}
+
+func TestValidateDefaultConfiguration(t *testing.T) {
+	t.Parallel()
+	errs := validateConfigurationData("config.yml", []byte(GetConfigString()))
+	for _, e := range errs {
+		assert.NotContains(t, e.Message, "unknown key")
+	}
+}
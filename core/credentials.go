@@ -0,0 +1,413 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"github.com/j-martin/bub/utils"
+	"github.com/manifoldco/promptui"
+	"github.com/tmc/keyring"
+	"os"
+	"os/user"
+	"path"
+	"runtime"
+	"strings"
+)
+
+const credentialService = "bub"
+
+var CredentialNotFound = errors.New("credential not found")
+
+// CredentialBackend stores the credentials, e.g. the OS keyring.
+type CredentialBackend interface {
+	Name() string
+	Get(item string) (string, error)
+	Set(item, value string) error
+	Delete(item string) error
+}
+
+// CredentialItem links a credential item to the configuration value it populates.
+type CredentialItem struct {
+	Name, Path string
+}
+
+func (cfg *Configuration) CredentialItems() []CredentialItem {
+	vaultAuthMethod := cfg.Vault.AuthMethod
+	if vaultAuthMethod == "" {
+		vaultAuthMethod = "Okta"
+	}
+	return []CredentialItem{
+		{"JIRA Username", "jira.username"},
+		{"JIRA Password", "jira.password"},
+		{"Confluence Username", "confluence.username"},
+		{"Confluence Password", "confluence.password"},
+		{"Jenkins Username", "jenkins.username"},
+		{"Jenkins Password", "jenkins.password"},
+		{"GitHub User", "github.username"},
+		{"GitHub Token", "github.token"},
+		{"Vault/" + vaultAuthMethod + " Username", "vault.username"},
+		{"Vault/" + vaultAuthMethod + " Password", "vault.password"},
+	}
+}
+
+func (cfg *Configuration) setCredentialSource(item, source string) {
+	for _, i := range cfg.CredentialItems() {
+		if i.Name == item {
+			cfg.setSource(i.Path, source)
+		}
+	}
+}
+
+// credentialEnvName returns the variable used for the item, e.g. "Confluence Username" -> "CONFLUENCE_USERNAME"
+func credentialEnvName(item string) string {
+	return strings.NewReplacer(" ", "_", "/", "_").Replace(strings.ToUpper(item))
+}
+
+func (cfg *Configuration) credentialBackend(item string) (CredentialBackend, error) {
+	backendCfg, ok := cfg.Credentials.Items[item]
+	if !ok || backendCfg.Backend == "" {
+		backendCfg.Backend = cfg.Credentials.Backend
+	}
+	switch backendCfg.Backend {
+	case "", "keyring":
+		return &keyringBackend{profile: cfg.Profile}, nil
+	case "env":
+		if backendCfg.Env == "" {
+			backendCfg.Env = credentialEnvName(item)
+		}
+		return &envBackend{variable: backendCfg.Env}, nil
+	case "command":
+		if backendCfg.Command == "" {
+			return nil, fmt.Errorf("no command defined for '%v' in 'credentials.items'", item)
+		}
+		return &commandBackend{command: backendCfg.Command}, nil
+	case "file":
+		if backendCfg.File == "" {
+			return nil, fmt.Errorf("no file defined for '%v' in 'credentials.items'", item)
+		}
+		return &fileBackend{
+			file:      expandHome(backendCfg.File),
+			identity:  expandHome(backendCfg.Identity),
+			recipient: backendCfg.Recipient,
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown credential backend '%v' for '%v'", backendCfg.Backend, item)
+}
+
+func (cfg *Configuration) LoadCredentials(item string, username, password *string) (err error) {
+	if err = cfg.LoadCredentialItem(item+" Username", username); err != nil {
+		return err
+	}
+	if err = cfg.LoadCredentialItem(item+" Password", password); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (cfg *Configuration) LoadCredentialItem(item string, ptr *string) (err error) {
+	if cfg.ResetCredentials {
+		return cfg.promptCredential(item, ptr)
+	}
+	err = cfg.LookupCredentialItem(item, ptr)
+	if err == CredentialNotFound {
+		return cfg.promptCredential(item, ptr)
+	}
+	return err
+}
+
+// LookupCredentialItem loads the item like LoadCredentialItem, without prompting for it if it is not found.
+func (cfg *Configuration) LookupCredentialItem(item string, ptr *string) (err error) {
+	envVar := os.Getenv(credentialEnvName(item))
+	if envVar != "" {
+		*ptr = envVar
+		cfg.setCredentialSource(item, SourceEnvironment)
+		return
+	}
+
+	if *ptr != "" && !strings.HasPrefix(*ptr, "<optional-") {
+		return nil
+	}
+
+	return cfg.lookupStoredCredential(item, ptr)
+}
+
+// LoadStoredCredential loads the item from its backend, prompts for it if it is not stored yet.
+func (cfg *Configuration) LoadStoredCredential(item string, ptr *string) error {
+	err := cfg.lookupStoredCredential(item, ptr)
+	if err == CredentialNotFound {
+		return cfg.promptCredential(item, ptr)
+	}
+	return err
+}
+
+func (cfg *Configuration) lookupStoredCredential(item string, ptr *string) error {
+	backend, err := cfg.credentialBackend(item)
+	if err != nil {
+		return err
+	}
+	value, err := backend.Get(item)
+	if err != nil {
+		return err
+	}
+	*ptr = value
+	cfg.setCredentialSource(item, backend.Name())
+	return nil
+}
+
+// PeekCredential returns the stored item without prompting.
+func (cfg *Configuration) PeekCredential(item string) (string, error) {
+	backend, err := cfg.credentialBackend(item)
+	if err != nil {
+		return "", err
+	}
+	return backend.Get(item)
+}
+
+func (cfg *Configuration) StoreCredential(item, value string) error {
+	backend, err := cfg.credentialBackend(item)
+	if err != nil {
+		return err
+	}
+	return backend.Set(item, value)
+}
+
+func (cfg *Configuration) DeleteCredential(item string) error {
+	backend, err := cfg.credentialBackend(item)
+	if err != nil {
+		return err
+	}
+	return backend.Delete(item)
+}
+
+// DescribeCredentialBackend returns the backend name and details, e.g. "command: pass show bub/github"
+func (cfg *Configuration) DescribeCredentialBackend(item string) string {
+	backend, err := cfg.credentialBackend(item)
+	if err != nil {
+		return err.Error()
+	}
+	return fmt.Sprint(backend)
+}
+
+func (cfg *Configuration) promptCredential(item string, ptr *string) (err error) {
+	backend, err := cfg.credentialBackend(item)
+	if err != nil {
+		return err
+	}
+	label := "Enter " + item
+	if cfg.Profile != "" {
+		label = label + " (" + cfg.Profile + ")"
+	}
+	prompt := promptui.Prompt{
+		Label: label,
+	}
+	if strings.HasSuffix(strings.ToLower(item), "password") || strings.HasSuffix(strings.ToLower(item), "token") {
+		prompt.Mask = '*'
+	}
+	result, err := prompt.Run()
+	if err != nil {
+		return err
+	}
+	err = backend.Set(item, string(result))
+	if err != nil {
+		return err
+	}
+	return cfg.LoadStoredCredential(item, ptr)
+}
+
+func expandHome(filePath string) string {
+	if !strings.HasPrefix(filePath, "~/") {
+		return filePath
+	}
+	usr, err := user.Current()
+	if err != nil {
+		return filePath
+	}
+	return path.Join(usr.HomeDir, filePath[2:])
+}
+
+type keyringBackend struct {
+	profile string
+}
+
+func (b *keyringBackend) Name() string {
+	return SourceKeyring
+}
+
+func (b *keyringBackend) String() string {
+	return SourceKeyring
+}
+
+// item scopes the item to the active profile, e.g. "work/JIRA Password".
+func (b *keyringBackend) item(item string) string {
+	if b.profile == "" {
+		return item
+	}
+	return b.profile + "/" + item
+}
+
+func (b *keyringBackend) Get(item string) (string, error) {
+	value, err := keyring.Get(credentialService, b.item(item))
+	if err == keyring.ErrNotFound {
+		return "", CredentialNotFound
+	}
+	return value, err
+}
+
+func (b *keyringBackend) Set(item, value string) error {
+	return keyring.Set(credentialService, b.item(item), value)
+}
+
+func (b *keyringBackend) Delete(item string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		return utils.RunCmd("security", "delete-generic-password", "-s", credentialService, "-a", b.item(item))
+	case "linux":
+		return utils.RunCmd("secret-tool", "clear", "service", credentialService, "username", b.item(item))
+	}
+	return fmt.Errorf("deleting keyring items is not supported on %v", runtime.GOOS)
+}
+
+type envBackend struct {
+	variable string
+}
+
+func (b *envBackend) Name() string {
+	return SourceEnvironment
+}
+
+func (b *envBackend) String() string {
+	return "env: " + b.variable
+}
+
+func (b *envBackend) Get(item string) (string, error) {
+	value := os.Getenv(b.variable)
+	if value == "" {
+		return "", CredentialNotFound
+	}
+	return value, nil
+}
+
+func (b *envBackend) Set(item, value string) error {
+	return fmt.Errorf("'%v' is read from the environment, export %v", item, b.variable)
+}
+
+func (b *envBackend) Delete(item string) error {
+	return b.Set(item, "")
+}
+
+type commandBackend struct {
+	command string
+}
+
+func (b *commandBackend) Name() string {
+	return SourceCommand
+}
+
+func (b *commandBackend) String() string {
+	return "command: " + b.command
+}
+
+func (b *commandBackend) Get(item string) (string, error) {
+	value, err := utils.RunCmdWithStdout("sh", "-c", b.command)
+	if err != nil {
+		return "", fmt.Errorf("'%v' failed: %v", b.command, err)
+	}
+	if value == "" {
+		return "", CredentialNotFound
+	}
+	return value, nil
+}
+
+func (b *commandBackend) Set(item, value string) error {
+	return fmt.Errorf("'%v' is read from '%v', store it with the command's tool", item, b.command)
+}
+
+func (b *commandBackend) Delete(item string) error {
+	return b.Set(item, "")
+}
+
+// fileBackend stores the secret in a file encrypted with age or GPG.
+type fileBackend struct {
+	file, identity, recipient string
+}
+
+func (b *fileBackend) Name() string {
+	return SourceFile
+}
+
+func (b *fileBackend) String() string {
+	return "file: " + b.file
+}
+
+func (b *fileBackend) isAge() bool {
+	return strings.HasSuffix(b.file, ".age") || b.identity != ""
+}
+
+func (b *fileBackend) Get(item string) (string, error) {
+	exists, err := utils.PathExists(b.file)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		return "", CredentialNotFound
+	}
+	var value string
+	if b.isAge() {
+		if b.identity == "" {
+			return "", fmt.Errorf("no identity defined to decrypt %v", b.file)
+		}
+		value, err = utils.RunCmdWithStdout("age", "--decrypt", "--identity", b.identity, b.file)
+	} else {
+		value, err = utils.RunCmdWithStdout("gpg", "--quiet", "--batch", "--decrypt", b.file)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt %v: %v", b.file, err)
+	}
+	return value, nil
+}
+
+func (b *fileBackend) Set(item, value string) error {
+	if b.recipient == "" {
+		return fmt.Errorf("no recipient defined to encrypt '%v' in %v", item, b.file)
+	}
+	if err := os.MkdirAll(path.Dir(b.file), 0700); err != nil {
+		return err
+	}
+	var err error
+	if b.isAge() {
+		_, err = utils.RunCmdWithInput(value, "age", "--encrypt", "--recipient", b.recipient, "--output", b.file)
+	} else {
+		_, err = utils.RunCmdWithInput(value, "gpg", "--batch", "--yes", "--encrypt", "--recipient", b.recipient, "--output", b.file)
+	}
+	if err != nil {
+		return err
+	}
+	return os.Chmod(b.file, 0600)
+}
+
+func (b *fileBackend) Delete(item string) error {
+	return os.Remove(b.file)
+}
+
+// ListCredentialItems returns the items of the enabled integrations and the ones configured in 'credentials.items'.
+func (cfg *Configuration) ListCredentialItems() (items []string) {
+	for _, i := range cfg.CredentialItems() {
+		if cfg.IsIntegrationEnabled(strings.Split(i.Path, ".")[0]) {
+			items = append(items, i.Name)
+		}
+	}
+	for name := range cfg.Credentials.Items {
+		if !utils.Contains(name, items...) {
+			items = append(items, name)
+		}
+	}
+	return items
+}
+
+// CheckKeyring returns an error if the OS keyring cannot be reached.
+func CheckKeyring() error {
+	_, err := (&keyringBackend{}).Get("bub doctor")
+	if err == CredentialNotFound {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,115 @@
+package core
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+func TestCredentialEnvName(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "CONFLUENCE_USERNAME", credentialEnvName("Confluence Username"))
+	assert.Equal(t, "GITHUB_TOKEN", credentialEnvName("GitHub Token"))
+	assert.Equal(t, "VAULT_LDAP_PASSWORD", credentialEnvName("Vault/LDAP Password"))
+
+	cfg := &Configuration{}
+	items := cfg.CredentialItems()
+	assert.Equal(t, CredentialItem{"Vault/Okta Password", "vault.password"}, items[len(items)-1])
+	cfg.Vault.AuthMethod = "LDAP"
+	items = cfg.CredentialItems()
+	assert.Equal(t, CredentialItem{"Vault/LDAP Username", "vault.username"}, items[len(items)-2])
+}
+
+func TestCredentialBackendSelection(t *testing.T) {
+	t.Parallel()
+	cfg := &Configuration{}
+	backend, err := cfg.credentialBackend("GitHub Token")
+	assert.NoError(t, err)
+	assert.Equal(t, SourceKeyring, backend.Name())
+
+	cfg.Credentials.Backend = "env"
+	cfg.Credentials.Items = map[string]CredentialBackendConfiguration{
+		"GitHub Token":        {Backend: "command", Command: "pass show bub/github-token"},
+		"JIRA Password":       {Env: "ATLASSIAN_TOKEN"},
+		"Confluence Password": {Backend: "command"},
+		"Jenkins Password":    {Backend: "vault"},
+	}
+	backend, err = cfg.credentialBackend("GitHub Token")
+	assert.NoError(t, err)
+	assert.Equal(t, "command: pass show bub/github-token", backend.(*commandBackend).String())
+
+	// the item without a backend uses the global one.
+	backend, err = cfg.credentialBackend("JIRA Password")
+	assert.NoError(t, err)
+	assert.Equal(t, "env: ATLASSIAN_TOKEN", backend.(*envBackend).String())
+	backend, err = cfg.credentialBackend("Vault/LDAP Password")
+	assert.NoError(t, err)
+	assert.Equal(t, "env: VAULT_LDAP_PASSWORD", backend.(*envBackend).String())
+
+	_, err = cfg.credentialBackend("Confluence Password")
+	assert.EqualError(t, err, "no command defined for 'Confluence Password' in 'credentials.items'")
+	_, err = cfg.credentialBackend("Jenkins Password")
+	assert.EqualError(t, err, "unknown credential backend 'vault' for 'Jenkins Password'")
+}
+
+func TestEnvCredentialBackend(t *testing.T) {
+	os.Setenv("BUB_TEST_TOKEN", "secret")
+	defer os.Unsetenv("BUB_TEST_TOKEN")
+	value, err := (&envBackend{variable: "BUB_TEST_TOKEN"}).Get("GitHub Token")
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", value)
+
+	_, err = (&envBackend{variable: "BUB_TEST_MISSING"}).Get("GitHub Token")
+	assert.Equal(t, CredentialNotFound, err)
+	assert.Error(t, (&envBackend{variable: "BUB_TEST_TOKEN"}).Set("GitHub Token", "other"))
+}
+
+func TestCommandCredentialBackend(t *testing.T) {
+	t.Parallel()
+	value, err := (&commandBackend{command: "echo secret"}).Get("GitHub Token")
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", value)
+
+	_, err = (&commandBackend{command: "true"}).Get("GitHub Token")
+	assert.Equal(t, CredentialNotFound, err)
+
+	_, err = (&commandBackend{command: "exit 3"}).Get("GitHub Token")
+	assert.EqualError(t, err, "'exit 3' failed: exit status 3")
+}
+
+func TestLookupCredentialItem(t *testing.T) {
+	cfg := &Configuration{}
+	cfg.Credentials.Items = map[string]CredentialBackendConfiguration{
+		"GitHub Token": {Backend: "command", Command: "echo from-command"},
+	}
+	var token string
+	assert.NoError(t, cfg.LookupCredentialItem("GitHub Token", &token))
+	assert.Equal(t, "from-command", token)
+	assert.Equal(t, SourceCommand, cfg.Sources["github.token"])
+
+	// the environment variable of the item takes precedence over the backend.
+	os.Setenv("GITHUB_TOKEN", "from-env")
+	defer os.Unsetenv("GITHUB_TOKEN")
+	assert.NoError(t, cfg.LookupCredentialItem("GitHub Token", &token))
+	assert.Equal(t, "from-env", token)
+	assert.Equal(t, SourceEnvironment, cfg.Sources["github.token"])
+}
+
+func TestListCredentialItems(t *testing.T) {
+	t.Parallel()
+	enabled, disabled := true, false
+	cfg := &Configuration{}
+	cfg.GitHub.Enabled = &enabled
+	cfg.JIRA.Enabled, cfg.Confluence.Enabled, cfg.Jenkins.Enabled = &disabled, &disabled, &disabled
+	cfg.Credentials.Items = map[string]CredentialBackendConfiguration{
+		"GitHub Token": {Backend: "env"},
+		"Splunk Token": {Backend: "env"},
+	}
+	assert.Equal(t, []string{
+		"GitHub User",
+		"GitHub Token",
+		"Vault/Okta Username",
+		"Vault/Okta Password",
+		"Splunk Token",
+	}, cfg.ListCredentialItems())
+}
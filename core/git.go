@@ -1,31 +1,33 @@
 package core
 
 import (
+	"context"
 	"fmt"
 	"github.com/j-martin/bub/utils"
 	"github.com/manifoldco/promptui"
 	"github.com/pkg/errors"
-	"io/ioutil"
 	"log"
 	"os"
 	"path"
 	"regexp"
+	"strconv"
 	"strings"
-	"sync"
 	"text/tabwriter"
+	"time"
 )
 
 type Git struct {
 	cfg *Configuration
+	ctx context.Context
 	dir string
+	// baseBranch is resolved once, see BaseBranch.
+	baseBranch string
 }
 
 type GitCommit struct {
 	Hash, Committer, Subject, Body string
 }
 
-type RepoOperation func(string) (string, error)
-
 func InitGit() *Git {
 	return &Git{}
 }
@@ -37,11 +39,52 @@ func MustInitGit(repoDir string) *Git {
 	return &Git{dir: repoDir}
 }
 
+// WithContext runs the git commands within the context, in their own process group, see utils.RunCmdContext.
+func (g *Git) WithContext(ctx context.Context) *Git {
+	g.ctx = ctx
+	return g
+}
+
+// WithConfig uses the config, e.g. for the default base branch.
+func (g *Git) WithConfig(cfg *Configuration) *Git {
+	g.cfg = cfg
+	return g
+}
+
+// BaseBranch returns the branch the work is based on, e.g. master, main or develop. In order, the git.baseBranch
+// of the repository's .bub.yml, the default branch of the remote (origin/HEAD), the git.baseBranch of the config
+// and master.
+func (g *Git) BaseBranch() string {
+	if g.baseBranch != "" {
+		return g.baseBranch
+	}
+	g.baseBranch = g.resolveBaseBranch()
+	return g.baseBranch
+}
+
+func (g *Git) resolveBaseBranch() string {
+	if branch, err := readRepositoryBaseBranch(g.dir); err != nil {
+		log.Printf("Failed to read the base branch of %v: %v", ConfigRepositoryFile, err)
+	} else if branch != "" {
+		return branch
+	}
+	if remoteHead, err := g.RunGitWithStdout("symbolic-ref", "--short", "-q", "refs/remotes/origin/HEAD"); err == nil && remoteHead != "" {
+		return strings.TrimPrefix(remoteHead, "origin/")
+	}
+	if g.cfg != nil && g.cfg.Git.BaseBranch != "" {
+		return g.cfg.Git.BaseBranch
+	}
+	return "master"
+}
+
 func (g *Git) RunGit(args ...string) error {
 	if g.dir != "" {
 		args = append([]string{"-C", g.dir}, args...)
 	}
 	log.Printf("Running: 'git %v'", strings.Join(args, " "))
+	if g.ctx != nil {
+		return utils.RunCmdContext(g.ctx, "git", args...)
+	}
 	return utils.RunCmd("git", args...)
 }
 
@@ -49,6 +92,9 @@ func (g *Git) RunGitWithStdout(args ...string) (string, error) {
 	if g.dir != "" {
 		args = append([]string{"-C", g.dir}, args...)
 	}
+	if g.ctx != nil {
+		return utils.RunCmdWithStdoutContext(g.ctx, "git", args...)
+	}
 	return utils.RunCmdWithStdout("git", args...)
 }
 
@@ -56,6 +102,9 @@ func (g *Git) RunGitWithFullOutput(args ...string) (string, error) {
 	if g.dir != "" {
 		args = append([]string{"-C", g.dir}, args...)
 	}
+	if g.ctx != nil {
+		return utils.RunCmdWithFullOutputContext(g.ctx, "git", args...)
+	}
 	return utils.RunCmdWithFullOutput("git", args...)
 }
 
@@ -91,14 +140,47 @@ func (g *Git) GetRepositoryRootPath() (string, error) {
 	return g.RunGitWithStdout("rev-parse", "--show-toplevel")
 }
 
+// ListFilesAt lists the files under the path at the ref, the paths are relative to the repository root.
+func (g *Git) ListFilesAt(ref, filePath string) ([]string, error) {
+	output, err := g.RunGitWithStdout("ls-tree", "-r", "--name-only", "--full-tree", ref, "--", filePath)
+	if err != nil || output == "" {
+		return nil, err
+	}
+	return strings.Split(output, "\n"), nil
+}
+
+// ListTrackedFiles lists the files tracked by git, relative to the repository root.
+func (g *Git) ListTrackedFiles() (files []string, err error) {
+	output, err := g.RunGitWithStdout("ls-files", "-z", "--full-name")
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range strings.Split(output, "\x00") {
+		if f != "" {
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
+
+// ShowFileAt returns the content of the file at the ref, e.g. ShowFileAt("main", "api/openapi.yaml")
+func (g *Git) ShowFileAt(ref, filePath string) ([]byte, error) {
+	output, err := g.RunGitWithStdout("show", ref+":"+filePath)
+	return []byte(output), err
+}
+
 func (g *Git) GetTitleFromBranchName() string {
 	branch := g.GetCurrentBranch()
 	return strings.Replace(strings.Replace(strings.Replace(branch, "-", "_", 1), "-", " ", -1), "_", "-", -1)
 }
 
-func (g *Git) Clone() (string, error) {
+// Clone clones the repository from the URL into the directory, e.g. from Configuration.CloneURL.
+func (g *Git) Clone(url string) (string, error) {
 	log.Printf("Cloning: %v", g.dir)
-	return utils.RunCmdWithFullOutput("git", "clone", "git@github.com:benchlabs/"+g.dir+".git")
+	if g.ctx != nil {
+		return utils.RunCmdWithFullOutputContext(g.ctx, "git", "clone", url, g.dir)
+	}
+	return utils.RunCmdWithFullOutput("git", "clone", url, g.dir)
 }
 
 func (g *Git) Push(cfg *Configuration) error {
@@ -116,14 +198,14 @@ func (g *Git) Sync(unStash bool) (string, error) {
 	dirtyTree := g.RunGit("diff-index", "--quiet", "HEAD", "--") != nil
 	if dirtyTree {
 		commands = append(commands, [][]string{
-			{"checkout", "master", "-f"},
+			{"checkout", g.BaseBranch(), "-f"},
 			{"stash", "save", "pre-update-" + utils.CurrentTimeForFilename()},
 		}...)
 	}
 	commands = append(commands, [][]string{
-		{"checkout", "master", "-f"},
+		{"checkout", g.BaseBranch(), "-f"},
 		{"clean", "-fd"},
-		{"checkout", "master", "."},
+		{"checkout", g.BaseBranch(), "."},
 		{"pull"},
 		{"pull", "--tags"},
 	}...)
@@ -139,52 +221,13 @@ func (g *Git) Sync(unStash bool) (string, error) {
 	return "", nil
 }
 
-type ConcurrentResult struct {
-	Output string
-	Err    error
-}
-
-type ConcurrentResults map[string]ConcurrentResult
-
-func ConcurrentRepositoryOperations(repos []string, fn RepoOperation) error {
-	var wg sync.WaitGroup
-	var mutex sync.Mutex
-	errs := ConcurrentResults{}
-	for _, r := range repos {
-		log.Printf("Sync: %v", r)
-		wg.Add(1)
-		go func(repo string) {
-			defer wg.Done()
-			output, err := fn(repo)
-			mutex.Lock()
-			errs[repo] = ConcurrentResult{Output: output, Err: err}
-			mutex.Unlock()
-			log.Printf("%v: done.", repo)
-		}(r)
-	}
-	wg.Wait()
-	errorCount := 0
-	for repo, result := range errs {
-		fmt.Println(result.Output)
-		if result.Err != nil {
-			errorCount++
-			log.Printf("%v failed to be updated: %v", repo, result.Err)
-		}
-	}
-	if errorCount > 0 {
-		log.Printf("%v repos failed to be updated.", errorCount)
-		return errors.New("some repos failed to update")
-	}
-	log.Print("All Done.")
-	return nil
-}
-
-func (g *Git) syncRepository() (string, error) {
+// CloneOrSync syncs the repository if it was already cloned, otherwise clones it from the URL.
+func (g *Git) CloneOrSync(url string) (string, error) {
 	repositoryExists, _ := utils.PathExists(g.dir)
 	if repositoryExists {
 		return g.Sync(true)
 	} else {
-		return g.Clone()
+		return g.Clone(url)
 	}
 }
 
@@ -200,6 +243,51 @@ func (g *Git) Log() (commits []*GitCommit) {
 	return commits
 }
 
+// GitFileChange is a file changed by a commit, see LogFileChanges.
+type GitFileChange struct {
+	Author, Email string
+	Time          time.Time
+	File          string
+	// Lines is the number of lines added and deleted, 0 for the binary files.
+	Lines int
+}
+
+// LogFileChanges lists the files changed by the commits since the date, e.g. '1 year ago'. The merges are ignored.
+func (g *Git) LogFileChanges(since string) ([]GitFileChange, error) {
+	output, err := g.RunGitWithStdout("log", "--no-merges", "--no-renames", "--since="+since, "--numstat", "--format=%x00%an%x09%ae%x09%at")
+	if err != nil {
+		return nil, err
+	}
+	return parseLogFileChanges(output), nil
+}
+
+func parseLogFileChanges(output string) (changes []GitFileChange) {
+	for _, commit := range strings.Split(output, "\x00") {
+		lines := strings.Split(strings.TrimSpace(commit), "\n")
+		header := strings.Split(lines[0], "\t")
+		if len(header) != 3 {
+			continue
+		}
+		timestamp, _ := strconv.ParseInt(header[2], 10, 64)
+		for _, line := range lines[1:] {
+			fields := strings.SplitN(line, "\t", 3)
+			if len(fields) != 3 {
+				continue
+			}
+			added, _ := strconv.Atoi(fields[0])
+			deleted, _ := strconv.Atoi(fields[1])
+			changes = append(changes, GitFileChange{
+				Author: header[0],
+				Email:  header[1],
+				Time:   time.Unix(timestamp, 0),
+				File:   fields[2],
+				Lines:  added + deleted,
+			})
+		}
+	}
+	return changes
+}
+
 func (g *Git) PendingChanges(cfg *Configuration, manifest *Manifest, previousVersion, currentVersion string, formatForSlack bool, noAt bool) {
 	table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
 	output := g.MustRunGitWithStdout("log", "--first-parent", "--pretty=format:%h\t\t%an\t%s", previousVersion+"..."+currentVersion)
@@ -207,9 +295,9 @@ func (g *Git) PendingChanges(cfg *Configuration, manifest *Manifest, previousVer
 		re := g.GetIssueRegex()
 		output = re.ReplaceAllString(output, "<https://"+cfg.JIRA.Server+"/browse/$1|$1>")
 		re = g.GetPRRegex()
-		output = re.ReplaceAllString(output, "<https://github.com/"+cfg.GitHub.Organization+"/"+manifest.Repository+"/pull/$2|PR#$2> ")
+		output = re.ReplaceAllString(output, "<"+cfg.GitHubURL(cfg.GitHub.Organization, manifest.Repository, "pull")+"/$2|PR#$2> ")
 		re = regexp.MustCompile("(?m:^)([a-z0-9]{6,})")
-		output = re.ReplaceAllString(output, "<https://github.com/"+cfg.GitHub.Organization+"/"+manifest.Repository+"/commit/$1|$1>")
+		output = re.ReplaceAllString(output, "<"+cfg.GitHubURL(cfg.GitHub.Organization, manifest.Repository, "commit")+"/$1|$1>")
 	}
 	fmt.Fprintln(table, output)
 	table.Flush()
@@ -274,16 +362,30 @@ func (g *Git) sanitizeBranchName(name string) string {
 	return strings.Trim(r2.ReplaceAllString(r.ReplaceAllString(name, "-"), "-"), "-")
 }
 
-func (g *Git) LogNotInMasterSubjects() []string {
-	return strings.Split(g.MustRunGitWithStdout("log", "HEAD", "--not", "origin/master", "--no-merges", "--pretty=format:%s"), "\n")
+// MergeBase returns the commit where HEAD forked from the base, on the remote first, e.g. origin/main,
+// then from the local branch when the remote does not have it, e.g. in a checkout without remote branches.
+func (g *Git) MergeBase(base string) (string, error) {
+	if commit, err := g.RunGitWithStdout("merge-base", "origin/"+base, "HEAD"); err == nil && commit != "" {
+		return commit, nil
+	}
+	return g.RunGitWithStdout("merge-base", base, "HEAD")
+}
+
+// remoteBaseBranch returns the base branch on the remote, e.g. origin/main.
+func (g *Git) remoteBaseBranch() string {
+	return "origin/" + g.BaseBranch()
+}
+
+func (g *Git) LogNotInBaseSubjects() []string {
+	return strings.Split(g.MustRunGitWithStdout("log", "HEAD", "--not", g.remoteBaseBranch(), "--no-merges", "--pretty=format:%s"), "\n")
 }
 
-func (g *Git) LogNotInMasterBody() string {
-	return g.MustRunGitWithStdout("log", "HEAD", "--not", "origin/master", "--no-merges", "--pretty=format:-> %B")
+func (g *Git) LogNotInBaseBody() string {
+	return g.MustRunGitWithStdout("log", "HEAD", "--not", g.remoteBaseBranch(), "--no-merges", "--pretty=format:-> %B")
 }
 
 func (g *Git) ListFileChanged() []string {
-	return strings.Split(g.MustRunGitWithStdout("diff", "HEAD", "--not", "origin/master", "--name-only"), "\n")
+	return strings.Split(g.MustRunGitWithStdout("diff", "HEAD", "--not", g.remoteBaseBranch(), "--name-only"), "\n")
 }
 
 func (g *Git) GetIssueKeyFromBranch() string {
@@ -331,12 +433,12 @@ func (g *Git) extractIssueKeyFromName(name string) string {
 
 func (g *Git) CreateBranch(name string) error {
 	name = g.sanitizeBranchName(name)
-	return g.RunGit("checkout", "-b", name, "origin/master")
+	return g.RunGit("checkout", "-b", name, g.remoteBaseBranch())
 }
 
 func (g *Git) ForceCreateBranch(name string) error {
 	name = g.sanitizeBranchName(name)
-	return g.RunGit("checkout", "-B", name, "origin/master")
+	return g.RunGit("checkout", "-B", name, g.remoteBaseBranch())
 }
 
 func (g *Git) CheckoutBranch() error {
@@ -347,22 +449,20 @@ func (g *Git) CheckoutBranch() error {
 	return g.RunGit("checkout", item)
 }
 
-func ForEachRepo(fn RepoOperation) error {
-	var repos []string
-	files, err := ioutil.ReadDir("./")
+// ForEachRepo applies the operation to the repositories of the workspace in the current directory matching the filter.
+func ForEachRepo(filter WorkspaceFilter, opts ConcurrencyOptions, fn RepoOperation) error {
+	w, err := LoadWorkspace(".")
 	if err != nil {
 		return err
 	}
-	for _, value := range files {
-		if !value.IsDir() {
-			continue
-		}
-		if !utils.IsRepository(value.Name()) {
-			continue
-		}
-		repos = append(repos, value.Name())
+	repos, err := w.SelectRepositories(filter)
+	if err != nil {
+		return err
+	}
+	if len(repos) == 0 {
+		return errors.New("no repository matches the filters")
 	}
-	return ConcurrentRepositoryOperations(repos, fn)
+	return ConcurrentRepositoryOperations(repos, opts, fn)
 }
 
 func (g *Git) getBranches() []string {
@@ -407,8 +507,8 @@ func (g *Git) ContainedUncommittedChanges() bool {
 	return utils.HasNonEmptyLines(strings.Split(g.MustRunGitWithStdout("status", "--short"), "\n"))
 }
 
-func (g *Git) IsDifferentFromMaster() bool {
-	return utils.HasNonEmptyLines(g.LogNotInMasterSubjects())
+func (g *Git) IsDifferentFromBase() bool {
+	return utils.HasNonEmptyLines(g.LogNotInBaseSubjects())
 }
 
 func (g *Git) ISDirty() bool {
@@ -416,7 +516,7 @@ func (g *Git) ISDirty() bool {
 }
 
 func (g *Git) Diff() (string, error) {
-	if !g.IsDifferentFromMaster() {
+	if !g.IsDifferentFromBase() {
 		return "", nil
 	}
 	return g.RunGitWithFullOutput("--no-pager", "diff")
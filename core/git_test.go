@@ -1,6 +1,10 @@
 package core
 
+import "io/ioutil"
+import "os"
+import "path"
 import "testing"
+import "time"
 import "github.com/stretchr/testify/assert"
 
 func TestSanitizeBranchName(t *testing.T) {
@@ -13,3 +17,53 @@ func TestExtractIssueKey(t *testing.T) {
 	t.Parallel()
 	assert.Equal(t, "PL-2345", InitGit().extractIssueKeyFromName("PL-2345-asfsd-asfsf-sffff"))
 }
+
+func TestParseLogFileChanges(t *testing.T) {
+	t.Parallel()
+	output := "\x00Jane Doe\tjane@example.com\t1500000000\n\n12\t3\tservices/billing/main.go\n-\t-\tdocs/logo.png\n" +
+		"\x00John\tjohn@example.com\t1500000100\n\n1\t0\tREADME.md"
+	assert.Equal(t, []GitFileChange{
+		{Author: "Jane Doe", Email: "jane@example.com", Time: time.Unix(1500000000, 0), File: "services/billing/main.go", Lines: 15},
+		{Author: "Jane Doe", Email: "jane@example.com", Time: time.Unix(1500000000, 0), File: "docs/logo.png", Lines: 0},
+		{Author: "John", Email: "john@example.com", Time: time.Unix(1500000100, 0), File: "README.md", Lines: 1},
+	}, parseLogFileChanges(output))
+}
+
+func TestBaseBranch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bub")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	g := MustInitGit(dir)
+	assert.NoError(t, g.RunGit("init", "-q"))
+
+	assert.Equal(t, "master", MustInitGit(dir).BaseBranch())
+	cfg := &Configuration{}
+	cfg.Git.BaseBranch = "trunk"
+	assert.Equal(t, "trunk", MustInitGit(dir).WithConfig(cfg).BaseBranch())
+
+	assert.NoError(t, g.RunGit("symbolic-ref", "refs/remotes/origin/HEAD", "refs/remotes/origin/develop"))
+	assert.Equal(t, "develop", MustInitGit(dir).WithConfig(cfg).BaseBranch())
+
+	assert.NoError(t, ioutil.WriteFile(path.Join(dir, ConfigRepositoryFile), []byte("git:\n  baseBranch: main\n"), 0600))
+	assert.Equal(t, "main", MustInitGit(dir).WithConfig(cfg).BaseBranch())
+}
+
+func TestCloneURL(t *testing.T) {
+	t.Parallel()
+	cfg := &Configuration{}
+	cfg.GitHub.Organization = "benchlabs"
+	assert.Equal(t, "git@github.com:benchlabs/bub.git", cfg.CloneURL("bub"))
+	cfg.GitHub.Host = "github.example.com"
+	cfg.GitHub.CloneProtocol = "https"
+	assert.Equal(t, "https://github.example.com/benchlabs/bub.git", cfg.CloneURL("bub"))
+}
+
+func TestGitHubURL(t *testing.T) {
+	t.Parallel()
+	cfg := &Configuration{}
+	assert.Equal(t, "https://github.com/benchlabs/bub/pull", cfg.GitHubURL("benchlabs", "bub", "pull"))
+	assert.Equal(t, "https://api.github.com", cfg.GitHubAPIURL())
+	cfg.GitHub.Host = "github.example.com"
+	assert.Equal(t, "https://github.example.com/benchlabs/bub/pull", cfg.GitHubURL("benchlabs", "bub", "pull"))
+	assert.Equal(t, "https://github.example.com/api/v3", cfg.GitHubAPIURL())
+}
@@ -1,25 +1,31 @@
 package core
 
 import (
+	"bytes"
 	"errors"
 	"github.com/j-martin/bub/utils"
 	"gopkg.in/yaml.v2"
-	"html/template"
 	"io/ioutil"
 	"log"
 	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
 	"time"
 )
 
 const manifestFile = ".bench.yml"
 
+// manifestFileNames are the names of the manifest files, by order of precedence.
+var manifestFileNames = []string{manifestFile, "manifest.yml"}
+
 type Ownership map[string][]User
 
 type Manifest struct {
 	Name          string
 	Active        bool
-	Repository    string
-	LastUpdate    int64
 	Platform      string // what is it running on
 	Platforms     []string
 	Language      string
@@ -27,14 +33,21 @@ type Manifest struct {
 	Types         []string
 	Dependencies  []Dependency
 	Protocols     []Protocol
-	Version       string
-	Branch        string
 	Deploy        Deploy
 	Documentation Documentation
-	Readme        string
-	ChangeLog     string
 	Page          string
-	Owners        Ownership
+	// The fields below are set when the manifest is loaded, they cannot be set in the file.
+	Repository string    `yaml:"-"`
+	LastUpdate int64     `yaml:"-"`
+	Version    string    `yaml:"-"`
+	Branch     string    `yaml:"-"`
+	Readme     string    `yaml:"-"`
+	ChangeLog  string    `yaml:"-"`
+	Owners     Ownership `yaml:"-"`
+	// Dir is the directory of the manifest relative to the root of the repository, empty for the root.
+	Dir string `yaml:"-"`
+	// BaseBranch is the base branch of the repository, e.g. main. Set when loaded from the repository.
+	BaseBranch string `yaml:"-"`
 }
 
 type Dependency struct {
@@ -47,7 +60,7 @@ type Dependency struct {
 	Version string
 	// e.g. why it depends on it
 	Description string
-	// service, database, front-end
+	// service, database, front-end, queue, cache or storage
 	Type string
 	// not managed / controlled by us on AWS or GCP
 	Dedicated bool
@@ -88,19 +101,188 @@ func (e Manifests) Swap(i, j int) {
 	e[i], e[j] = e[j], e[i]
 }
 
-func LoadManifest() (*Manifest, error) {
+// LoadManifest loads the manifest of the current directory. In a monorepo, the closest manifest
+// found from the current directory up to the root of the repository is used.
+func LoadManifest(cfg *Configuration) (*Manifest, error) {
 	m := &Manifest{}
 
 	if !utils.InRepository() {
 		return m, errors.New("must be executed in a repository")
 	}
 
-	data, err := ioutil.ReadFile(manifestFile)
+	root, err := InitGit().GetRepositoryRootPath()
+	if err != nil {
+		return m, err
+	}
+	dir, err := FindManifestDir(root, ".")
 	if err != nil {
-		data, err = ioutil.ReadFile("manifest.yml")
+		return m, err
+	}
+
+	var data []byte
+	if manifestPath, err := getManifestPath(dir); err == nil {
+		data, err = ioutil.ReadFile(manifestPath)
+		if err != nil {
+			return m, err
+		}
+	}
+	if m.Dir, err = manifestRelativeDir(root, dir); err != nil {
+		return m, err
 	}
 	err = yaml.Unmarshal(data, m)
+	m.normalize()
+	if m.Name == "" && m.Dir != "" {
+		m.Name = path.Base(m.Dir)
+	}
+
+	m.LastUpdate = time.Now().Unix()
+	m.Repository = InitGit().GetCurrentRepositoryName()
+	m.Branch = InitGit().GetCurrentBranch()
+	m.BaseBranch = InitGit().WithConfig(cfg).BaseBranch()
+
+	readme, _ := ioutil.ReadFile(path.Join(dir, "README.md"))
+	m.Readme = string(readme)
+
+	changelog, _ := ioutil.ReadFile(path.Join(dir, "CHANGELOG.md"))
+	m.ChangeLog = string(changelog)
+
+	return m, err
+}
+
+// FindManifestDir returns the closest directory containing a manifest, from the directory up to the root
+// of the repository. The root is returned if there is no manifest.
+func FindManifestDir(root, dir string) (string, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		if _, err := getManifestPath(dir); err == nil {
+			return dir, nil
+		}
+		if dir == root || !strings.HasPrefix(dir, root) {
+			return root, nil
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// manifestRelativeDir returns the directory relative to the root of the repository, empty for the root.
+func manifestRelativeDir(root, dir string) (string, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(root, dir)
+	if err != nil || rel == "." {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// findManifestPaths returns the path of every manifest of the repository, including the nested ones.
+func findManifestPaths(repoDir string) (manifestPaths []string, err error) {
+	err = filepath.Walk(repoDir, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if filePath != repoDir && (skippedDirs[info.Name()] || strings.HasPrefix(info.Name(), ".")) {
+			return filepath.SkipDir
+		}
+		if manifestPath, err := getManifestPath(filePath); err == nil {
+			manifestPaths = append(manifestPaths, manifestPath)
+		}
+		return nil
+	})
+	return manifestPaths, err
+}
+
+// FindManifests returns every manifest of the repository, including the nested ones of a monorepo.
+func FindManifests(repoDir, repository string) (manifests Manifests, err error) {
+	manifestPaths, err := findManifestPaths(repoDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, manifestPath := range manifestPaths {
+		data, err := ioutil.ReadFile(manifestPath)
+		if err != nil {
+			return nil, err
+		}
+		manifestDir, err := manifestRelativeDir(repoDir, filepath.Dir(manifestPath))
+		if err != nil {
+			return nil, err
+		}
+		m, err := ParseRepositoryManifest(repository, manifestDir, data)
+		if err != nil {
+			log.Printf("Skipping the invalid manifest %v", err)
+			continue
+		}
+		manifests = append(manifests, *m)
+	}
+	return manifests, nil
+}
+
+// ParseRepositoryManifest parses a manifest of the repository, manifestDir is its directory relative to the
+// root of the repository, empty for the root. The nested manifests are named after their directory by default.
+func ParseRepositoryManifest(repository, manifestDir string, data []byte) (*Manifest, error) {
+	defaultName := repository
+	if manifestDir != "" {
+		defaultName = path.Base(manifestDir)
+	}
+	m, err := ParseManifest(defaultName, data)
+	if err != nil {
+		return nil, err
+	}
+	m.Repository, m.Dir = repository, manifestDir
+	return m, nil
+}
 
+// SelectManifestPaths returns the manifests among the files of a repository (e.g. listed by the GitHub API),
+// following the same rules as FindManifests. The paths are relative to the root of the repository.
+func SelectManifestPaths(filePaths []string) (manifestPaths []string) {
+	files := map[string]bool{}
+	dirs := map[string]bool{}
+	for _, f := range filePaths {
+		files[f] = true
+		if utils.Contains(path.Base(f), manifestFileNames...) && !isSkippedPath(path.Dir(f)) {
+			dirs[path.Dir(f)] = true
+		}
+	}
+	var sortedDirs []string
+	for dir := range dirs {
+		sortedDirs = append(sortedDirs, dir)
+	}
+	sort.Strings(sortedDirs)
+	for _, dir := range sortedDirs {
+		for _, name := range manifestFileNames {
+			if files[path.Join(dir, name)] {
+				manifestPaths = append(manifestPaths, path.Join(dir, name))
+				break
+			}
+		}
+	}
+	return manifestPaths
+}
+
+// isSkippedPath returns true if one of the directories of the relative path is not searched for manifests.
+func isSkippedPath(dir string) bool {
+	for _, name := range strings.Split(dir, "/") {
+		if skippedDirs[name] || (strings.HasPrefix(name, ".") && name != ".") {
+			return true
+		}
+	}
+	return false
+}
+
+// normalize fills the singular and plural variants of the fields and the defaults.
+func (m *Manifest) normalize() {
 	if len(m.Languages) == 0 && m.Language != "" {
 		m.Languages = []string{m.Language}
 	}
@@ -124,67 +306,96 @@ func LoadManifest() (*Manifest, error) {
 	if m.Page != "" {
 		m.Documentation.PageId = m.Page
 	}
-
-	m.LastUpdate = time.Now().Unix()
-	m.Repository = InitGit().GetCurrentRepositoryName()
-	m.Branch = InitGit().GetCurrentBranch()
-
-	readme, _ := ioutil.ReadFile("README.md")
-	m.Readme = string(readme)
-
-	changelog, _ := ioutil.ReadFile("CHANGELOG.md")
-	m.ChangeLog = string(changelog)
-
-	return m, err
 }
 
-func CreateManifest() {
-
-	manifest := Manifest{
-		Name: InitGit().GetCurrentRepositoryName(),
-	}
-	manifestString := `---
+var manifestTemplate = `---
 name: {{.Name}}
-active: true
+active: {{.Active}}
 languages:
-	- scala
+{{- range .Languages}}
+	- {{.}}
+{{- else}}
+	# - go
+{{- end}}
 types:
-	- service
+{{- range .Types}}
+	- {{.}}
+{{- else}}
+	# - service
+{{- end}}
+{{- if .Platforms}}
+platforms:
+{{- range .Platforms}}
+	- {{.}}
+{{- end}}
+{{- end}}
 dependencies:
-	- name: activemq
-		direction: both
-	- name: postgres
-		version: 9.6
+{{- range .Dependencies}}
+	- name: {{.Name}}
+		type: {{.Type}}
+{{- if .Version}}
+		version: "{{.Version}}"
+{{- end}}
+{{- if .Description}}
+		description: {{printf "%q" .Description}}
+{{- end}}
+{{- else}}
+	# - name: postgres
+	#   type: database
+	#   direction: out # (default), in or both
+{{- end}}
 protocols:
-	- type: raml
-		path: client/src/main/raml
+{{- range .Protocols}}
+	- type: {{.Type}}
+		path: {{.Path}}
+{{- else}}
+	# - type: raml
+	#   path: client/src/main/raml
+{{- end}}
 documentation:
-	pageId: pageID from confluence, not the name.
+	pageId:{{if .Documentation.PageId}} "{{.Documentation.PageId}}"{{else}} # id of the page in Confluence, not the name.{{end}}
 	ignoredDirs:
-		- optional/dir/to/be/ignored/from/the/docs
+		# - optional/dir/to/be/ignored/from/the/docs
 `
-	manifestTemplate, err := template.New("manifest").Parse(manifestString)
+
+// WriteManifest writes the manifest with the comments of the template.
+func WriteManifest(manifestPath string, m Manifest) error {
+	content, err := renderManifest(m)
 	if err != nil {
-		panic(err)
+		return err
 	}
+	return ioutil.WriteFile(manifestPath, []byte(content), 0644)
+}
 
+func renderManifest(m Manifest) (string, error) {
+	t, err := template.New("manifest").Parse(strings.Replace(manifestTemplate, "\t", "  ", -1))
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	err = t.Execute(&buf, m)
+	return buf.String(), err
+}
+
+// CreateManifest creates the manifest pre-filled with what was detected in the repository,
+// then opens the editor.
+func CreateManifest() {
 	fileExists, err := utils.PathExists(manifestFile)
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	if !fileExists {
-		log.Println("Creating manifest.")
-		writer, err := os.Create(manifestFile)
+		log.Println("Creating manifest from the content of the repository.")
+		err = WriteManifest(manifestFile, DetectManifest(".", InitGit().GetCurrentRepositoryName()))
 		if err != nil {
 			log.Fatal(err)
 		}
-		manifestTemplate.Execute(writer, manifest)
 	}
 
 	log.Println("Edit the manifest file.")
 	utils.EditFile(manifestFile)
-	log.Println("Done. Don't forget to add and commit the file.")
+	log.Println("Done. Don't forget to add and commit the file, and to run 'bub manifest validate'.")
 }
 
 func IsSameType(m Manifest, manifestType string) bool {
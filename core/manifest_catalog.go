@@ -0,0 +1,158 @@
+package core
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/j-martin/bub/utils"
+	"gopkg.in/yaml.v2"
+	"io"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// ManifestFormats are the output formats of WriteManifests.
+var ManifestFormats = []string{"table", "json", "csv"}
+
+// ParseManifest parses the content of a manifest that is not in the current repository.
+// The name defaults to the name of the repository.
+func ParseManifest(repository string, data []byte) (*Manifest, error) {
+	m := &Manifest{}
+	if err := yaml.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("%v: %v", repository, err)
+	}
+	m.normalize()
+	m.Repository = repository
+	if m.Name == "" {
+		m.Name = repository
+	}
+	return m, nil
+}
+
+// LoadWorkspaceManifests loads the manifests of every repository of the workspace directory,
+// including the nested manifests of the monorepos. The repositories without manifest are skipped.
+func LoadWorkspaceManifests(workspaceDir string) (Manifests, error) {
+	files, err := ioutil.ReadDir(workspaceDir)
+	if err != nil {
+		return nil, err
+	}
+	var manifests Manifests
+	for _, f := range files {
+		repoDir := path.Join(workspaceDir, f.Name())
+		if !f.IsDir() || !utils.IsRepository(repoDir) {
+			continue
+		}
+		repoManifests, err := FindManifests(repoDir, f.Name())
+		if err != nil {
+			return nil, err
+		}
+		manifests = append(manifests, repoManifests...)
+	}
+	sort.Sort(manifests)
+	return manifests, nil
+}
+
+// ManifestFilter selects manifests, the empty fields match everything.
+type ManifestFilter struct {
+	Type, Language, Platform string
+	// OnlyActive or OnlyInactive, to filter on the active flag.
+	OnlyActive, OnlyInactive bool
+}
+
+func (f ManifestFilter) Match(m Manifest) bool {
+	return (f.Type == "" || IsSameType(m, f.Type)) &&
+		(f.Language == "" || containsFold(m.Languages, f.Language)) &&
+		(f.Platform == "" || containsFold(m.Platforms, f.Platform)) &&
+		(!f.OnlyActive || m.Active) &&
+		(!f.OnlyInactive || !m.Active)
+}
+
+func containsFold(items []string, item string) bool {
+	for _, i := range items {
+		if strings.EqualFold(i, item) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e Manifests) Filter(f ManifestFilter) (manifests Manifests) {
+	for _, m := range e {
+		if f.Match(m) {
+			manifests = append(manifests, m)
+		}
+	}
+	return manifests
+}
+
+type manifestSummary struct {
+	Name         string   `json:"name"`
+	Repository   string   `json:"repository"`
+	Active       bool     `json:"active"`
+	Types        []string `json:"types"`
+	Languages    []string `json:"languages"`
+	Platforms    []string `json:"platforms"`
+	Dependencies []string `json:"dependencies"`
+}
+
+func summarizeManifest(m Manifest) manifestSummary {
+	s := manifestSummary{
+		Name:       m.Name,
+		Repository: m.Repository,
+		Active:     m.Active,
+		Types:      m.Types,
+		Languages:  m.Languages,
+		Platforms:  m.Platforms,
+	}
+	for _, d := range m.Dependencies {
+		s.Dependencies = append(s.Dependencies, d.Name)
+	}
+	return s
+}
+
+func (s manifestSummary) fields() []string {
+	return []string{
+		s.Name,
+		s.Repository,
+		strconv.FormatBool(s.Active),
+		strings.Join(s.Types, ","),
+		strings.Join(s.Languages, ","),
+		strings.Join(s.Platforms, ","),
+		strings.Join(s.Dependencies, ","),
+	}
+}
+
+// WriteManifests writes the inventory of the manifests as a table, json or csv.
+func WriteManifests(w io.Writer, manifests Manifests, format string) error {
+	var summaries []manifestSummary
+	for _, m := range manifests {
+		summaries = append(summaries, summarizeManifest(m))
+	}
+	header := []string{"Name", "Repository", "Active", "Types", "Languages", "Platforms", "Dependencies"}
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(summaries)
+	case "csv":
+		writer := csv.NewWriter(w)
+		writer.Write(header)
+		for _, s := range summaries {
+			writer.Write(s.fields())
+		}
+		writer.Flush()
+		return writer.Error()
+	case "table", "":
+		table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(table, strings.Join(header, "\t"))
+		for _, s := range summaries {
+			fmt.Fprintln(table, strings.Join(s.fields(), "\t"))
+		}
+		return table.Flush()
+	}
+	return fmt.Errorf("unknown format '%v', must be one of: %v", format, strings.Join(ManifestFormats, ", "))
+}
@@ -0,0 +1,40 @@
+package core
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func testManifests(t *testing.T) Manifests {
+	var manifests Manifests
+	for repo, content := range map[string]string{
+		"mainapp":  "name: mainapp\nactive: true\nlanguage: scala\ntypes: [service]\ndependencies:\n  - name: postgres\n  - name: billing\n",
+		"billing":  "name: billing\nactive: true\nlanguages: [go]\nplatforms: [kubernetes]\ntypes: [service]\ndependencies:\n  - name: postgres\n    external: true\n",
+		"frontend": "active: false\nlanguages: [javascript]\ntypes: [front-end]\ndependencies:\n  - name: mainapp\n    implicit: true\n",
+	} {
+		m, err := ParseManifest(repo, []byte(content))
+		assert.NoError(t, err)
+		manifests = append(manifests, *m)
+	}
+	return manifests
+}
+
+func TestManifestFilter(t *testing.T) {
+	manifests := testManifests(t)
+	assert.Len(t, manifests.Filter(ManifestFilter{}), 3)
+	assert.Len(t, manifests.Filter(ManifestFilter{Type: "service"}), 2)
+	assert.Len(t, manifests.Filter(ManifestFilter{Language: "Scala"}), 1)
+	assert.Len(t, manifests.Filter(ManifestFilter{Platform: "kubernetes"}), 1)
+	inactive := manifests.Filter(ManifestFilter{OnlyInactive: true})
+	assert.Len(t, inactive, 1)
+	assert.Equal(t, "frontend", inactive[0].Name)
+}
+
+func TestWriteManifests(t *testing.T) {
+	manifests := testManifests(t).Filter(ManifestFilter{Language: "go"})
+	var buf bytes.Buffer
+	assert.NoError(t, WriteManifests(&buf, manifests, "csv"))
+	assert.Equal(t, "Name,Repository,Active,Types,Languages,Platforms,Dependencies\nbilling,billing,true,service,go,kubernetes,postgres\n", buf.String())
+	assert.Error(t, WriteManifests(&buf, manifests, "xml"))
+}
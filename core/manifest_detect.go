@@ -0,0 +1,189 @@
+package core
+
+import (
+	"encoding/json"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// skippedDirs are not inspected when detecting the protocols.
+var skippedDirs = map[string]bool{".git": true, "node_modules": true, "vendor": true, "target": true, "build": true, "dist": true}
+
+// detectableLanguages are the languages DetectManifest can find.
+var detectableLanguages = []string{"go", "scala", "javascript", "typescript"}
+
+// dependencyImageTypes maps the docker images to the dependency types.
+var dependencyImageTypes = map[string]string{
+	"postgres":      "database",
+	"mysql":         "database",
+	"mariadb":       "database",
+	"mongo":         "database",
+	"elasticsearch": "database",
+	"redis":         "cache",
+	"memcached":     "cache",
+	"activemq":      "queue",
+	"rabbitmq":      "queue",
+	"kafka":         "queue",
+	"nats":          "queue",
+	"minio":         "storage",
+	"localstack":    "storage",
+}
+
+// DetectManifest inspects the repository to pre-fill the manifest.
+func DetectManifest(repoDir, name string) Manifest {
+	m := Manifest{Name: name, Active: true}
+	exists := func(file string) bool {
+		_, err := os.Stat(path.Join(repoDir, file))
+		return err == nil
+	}
+	if exists("go.mod") {
+		m.Languages = append(m.Languages, "go")
+	}
+	if exists("build.sbt") {
+		m.Languages = append(m.Languages, "scala")
+	}
+	isFrontEnd := false
+	if exists("package.json") {
+		language, frontEnd := detectPackageJSON(path.Join(repoDir, "package.json"))
+		m.Languages = append(m.Languages, language)
+		isFrontEnd = frontEnd
+	}
+	isService := exists("Dockerfile")
+	if isService {
+		m.Platforms = append(m.Platforms, "docker")
+	}
+	for _, s := range detectComposeServices(repoDir) {
+		m.Dependencies = append(m.Dependencies, s.Dependency)
+	}
+	switch {
+	case isFrontEnd:
+		m.Types = []string{"front-end"}
+	case isService:
+		m.Types = []string{"service"}
+	case len(m.Languages) > 0:
+		m.Types = []string{"library"}
+	}
+	m.Protocols = detectProtocols(repoDir)
+	return m
+}
+
+func detectPackageJSON(file string) (language string, frontEnd bool) {
+	language = "javascript"
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return language, false
+	}
+	pkg := struct {
+		Dependencies    map[string]string
+		DevDependencies map[string]string
+	}{}
+	if json.Unmarshal(data, &pkg) != nil {
+		return language, false
+	}
+	has := func(dep string) bool {
+		_, ok := pkg.Dependencies[dep]
+		_, okDev := pkg.DevDependencies[dep]
+		return ok || okDev
+	}
+	if has("typescript") {
+		language = "typescript"
+	}
+	return language, has("react") || has("vue") || has("@angular/core")
+}
+
+// composeService is a dependency found in docker-compose.yml.
+type composeService struct {
+	Dependency
+	// Image is the name of the image without registry and tag, e.g. postgres.
+	Image string
+}
+
+// detectComposeServices infers the dependencies from the compose services using an image.
+// The services built from the repository are ignored.
+func detectComposeServices(repoDir string) (services []composeService) {
+	for _, composeFile := range []string{"docker-compose.yml", "docker-compose.yaml"} {
+		data, err := ioutil.ReadFile(path.Join(repoDir, composeFile))
+		if err != nil {
+			continue
+		}
+		compose := struct {
+			Services map[string]struct {
+				Image string
+				Build interface{}
+			}
+		}{}
+		if yaml.Unmarshal(data, &compose) != nil {
+			continue
+		}
+		for name, service := range compose.Services {
+			if service.Build != nil || service.Image == "" {
+				continue
+			}
+			image := service.Image
+			version := ""
+			if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+				image, version = image[:i], image[i+1:]
+			}
+			image = path.Base(image)
+			depType := "service"
+			for prefix, t := range dependencyImageTypes {
+				if strings.HasPrefix(image, prefix) {
+					depType = t
+				}
+			}
+			if version == "latest" {
+				version = ""
+			}
+			services = append(services, composeService{Dependency{Name: name, Version: version, Type: depType}, image})
+		}
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].Name < services[j].Name })
+	return services
+}
+
+// detectProtocols finds the proto, OpenAPI and RAML definitions, except in the directories with their own
+// manifest. The proto and RAML protocols point to the directory containing the files.
+func detectProtocols(repoDir string) (protocols []Protocol) {
+	seen := map[Protocol]bool{}
+	filepath.Walk(repoDir, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if skippedDirs[info.Name()] {
+				return filepath.SkipDir
+			}
+			// the nested services of a monorepo declare their protocols in their own manifest.
+			if _, err := getManifestPath(filePath); err == nil && filePath != repoDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(repoDir, filePath)
+		if err != nil {
+			return nil
+		}
+		var p Protocol
+		switch name := info.Name(); {
+		case strings.HasSuffix(name, ".proto"):
+			p = Protocol{Type: "proto", Path: filepath.Dir(rel)}
+		case strings.HasSuffix(name, ".raml"):
+			p = Protocol{Type: "raml", Path: filepath.Dir(rel)}
+		case name == "openapi.yaml" || name == "openapi.yml" || name == "openapi.json":
+			p = Protocol{Type: "openapi", Path: rel}
+		default:
+			return nil
+		}
+		if !seen[p] {
+			seen[p] = true
+			protocols = append(protocols, p)
+		}
+		return nil
+	})
+	return protocols
+}
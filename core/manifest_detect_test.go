@@ -0,0 +1,56 @@
+package core
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestDetectManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bub")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	writeFiles(t, dir, map[string]string{
+		"go.mod":     "module github.com/benchlabs/billing\n",
+		"Dockerfile": "FROM scratch\n",
+		"docker-compose.yml": `version: "3"
+services:
+  billing:
+    build: .
+  db:
+    image: postgres:9.6
+  cache:
+    image: redis
+  ledger:
+    image: registry.example.com/benchlabs/ledger:latest
+`,
+		"api/billing.proto":           "syntax = \"proto3\";\n",
+		"api/invoice.proto":           "syntax = \"proto3\";\n",
+		"docs/openapi.yaml":           "openapi: 3.0.0\n",
+		"vendor/lib/ignored.proto":    "",
+		"node_modules/x/ignored.raml": "",
+	})
+
+	m := DetectManifest(dir, "billing")
+	assert.Equal(t, []string{"go"}, m.Languages)
+	assert.Equal(t, []string{"service"}, m.Types)
+	assert.Equal(t, []string{"docker"}, m.Platforms)
+	assert.Equal(t, []Dependency{
+		{Name: "cache", Type: "cache"},
+		{Name: "db", Version: "9.6", Type: "database"},
+		{Name: "ledger", Type: "service"},
+	}, m.Dependencies)
+	assert.Equal(t, []Protocol{{Type: "proto", Path: "api"}, {Type: "openapi", Path: "docs/openapi.yaml"}}, m.Protocols)
+
+	content, err := renderManifest(m)
+	assert.NoError(t, err)
+	assert.Empty(t, validateManifestData(".bench.yml", dir, []byte(content)))
+}
+
+func TestRenderEmptyManifest(t *testing.T) {
+	content, err := renderManifest(Manifest{Name: "empty"})
+	assert.NoError(t, err)
+	errs := validateManifestData(".bench.yml", ".", []byte(content))
+	assert.Len(t, errs, 2, "languages and types must be filled")
+}
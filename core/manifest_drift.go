@@ -0,0 +1,268 @@
+package core
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+)
+
+// ManifestDrift is a mismatch between the manifest and the content of the repository.
+type ManifestDrift struct {
+	Message string
+	// Fix describes the change applied by --fix.
+	Fix   string
+	apply func(root *yaml.Node)
+}
+
+func scalarNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+// sequenceNode returns the sequence at the key, created if missing.
+func sequenceNode(root *yaml.Node, key string) *yaml.Node {
+	n := findNode(root, key)
+	if n == nil || n.Kind != yaml.SequenceNode {
+		setNodeValue(root, []string{key}, &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"})
+		n = findNode(root, key)
+	}
+	return n
+}
+
+// languagesNode returns the languages sequence, the legacy 'language' key is converted.
+func languagesNode(root *yaml.Node) *yaml.Node {
+	if i := mappingKeyIndex(root, "language"); i >= 0 && findNode(root, "languages") == nil {
+		language := root.Content[i+1]
+		root.Content[i].Value = "languages"
+		root.Content[i+1] = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Content: []*yaml.Node{language}}
+	}
+	return sequenceNode(root, "languages")
+}
+
+// removeSequenceItems removes the items matching the function.
+func removeSequenceItems(n *yaml.Node, match func(item *yaml.Node) bool) {
+	var content []*yaml.Node
+	for _, item := range n.Content {
+		if !match(item) {
+			content = append(content, item)
+		}
+	}
+	n.Content = content
+}
+
+func nodeValue(n *yaml.Node, key string) string {
+	if v := findNode(n, key); v != nil {
+		return v.Value
+	}
+	return ""
+}
+
+func sameVersion(declared, actual string) bool {
+	return declared == actual || strings.HasPrefix(actual, declared+".") || strings.HasPrefix(actual, declared+"-")
+}
+
+// CheckManifestDrift compares the dependencies, languages and protocols of the manifest with the repository.
+func CheckManifestDrift(repoDir string) ([]ManifestDrift, error) {
+	manifestPath, err := getManifestPath(repoDir)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	m, err := ParseManifest(path.Base(repoDir), data)
+	if err != nil {
+		return nil, err
+	}
+	detected := DetectManifest(repoDir, m.Name)
+	drifts := checkLanguagesDrift(m.Languages, detected.Languages)
+	drifts = append(drifts, checkDependenciesDrift(m.Dependencies, detectComposeServices(repoDir))...)
+	drifts = append(drifts, checkProtocolsDrift(repoDir, m.Protocols, detected.Protocols)...)
+	return drifts, nil
+}
+
+func checkLanguagesDrift(declared, detected []string) (drifts []ManifestDrift) {
+	for _, l := range detected {
+		language := l
+		if containsFold(declared, language) {
+			continue
+		}
+		drifts = append(drifts, ManifestDrift{
+			Message: fmt.Sprintf("languages: '%v' is used in the repository but not declared", language),
+			Fix:     fmt.Sprintf("add '%v' to languages", language),
+			apply: func(root *yaml.Node) {
+				n := languagesNode(root)
+				n.Content = append(n.Content, scalarNode(language))
+			},
+		})
+	}
+	for _, l := range declared {
+		language := l
+		if !containsFold(detectableLanguages, language) || containsFold(detected, language) {
+			continue
+		}
+		drifts = append(drifts, ManifestDrift{
+			Message: fmt.Sprintf("languages: '%v' is declared but not found in the repository", language),
+			Fix:     fmt.Sprintf("remove '%v' from languages", language),
+			apply: func(root *yaml.Node) {
+				removeSequenceItems(languagesNode(root), func(item *yaml.Node) bool {
+					return strings.EqualFold(item.Value, language)
+				})
+			},
+		})
+	}
+	return drifts
+}
+
+func checkDependenciesDrift(declared []Dependency, services []composeService) (drifts []ManifestDrift) {
+	for _, s := range services {
+		service := s
+		var dep *Dependency
+		for i, d := range declared {
+			if d.Name == service.Name || d.Name == service.Image || d.UniqueName == service.Name {
+				dep = &declared[i]
+			}
+		}
+		if dep == nil {
+			drifts = append(drifts, ManifestDrift{
+				Message: fmt.Sprintf("dependencies: '%v' (%v) is in docker-compose.yml but not declared", service.Name, service.Image),
+				Fix:     fmt.Sprintf("add the dependency '%v'", service.Name),
+				apply: func(root *yaml.Node) {
+					// named after the compose service, as in 'bub manifest create'.
+					item := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+					setNodeValue(item, []string{"name"}, scalarNode(service.Name))
+					setNodeValue(item, []string{"type"}, scalarNode(service.Type))
+					if service.Version != "" {
+						setNodeValue(item, []string{"version"}, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: service.Version, Style: yaml.DoubleQuotedStyle})
+					}
+					n := sequenceNode(root, "dependencies")
+					n.Content = append(n.Content, item)
+				},
+			})
+			continue
+		}
+		if dep.Version == "" || service.Version == "" || sameVersion(dep.Version, service.Version) {
+			continue
+		}
+		name := dep.Name
+		drifts = append(drifts, ManifestDrift{
+			Message: fmt.Sprintf("dependencies: '%v' is declared as version %v but docker-compose.yml uses %v", name, dep.Version, service.Version),
+			Fix:     fmt.Sprintf("set the version of '%v' to %v", name, service.Version),
+			apply: func(root *yaml.Node) {
+				for _, item := range sequenceItems(findNode(root, "dependencies")) {
+					if nodeValue(item, "name") == name {
+						setNodeValue(item, []string{"version"}, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: service.Version, Style: yaml.DoubleQuotedStyle})
+					}
+				}
+			},
+		})
+	}
+	return drifts
+}
+
+func checkProtocolsDrift(repoDir string, declared, detected []Protocol) (drifts []ManifestDrift) {
+	isDeclared := func(p Protocol) bool {
+		for _, d := range declared {
+			if d.Type == p.Type && path.Clean(d.Path) == path.Clean(p.Path) {
+				return true
+			}
+		}
+		return false
+	}
+	var undeclared []Protocol
+	for _, p := range detected {
+		if !isDeclared(p) {
+			undeclared = append(undeclared, p)
+		}
+	}
+
+	moved := map[Protocol]bool{}
+	for _, d := range declared {
+		protocol := d
+		if _, err := os.Stat(path.Join(repoDir, protocol.Path)); err == nil {
+			continue
+		}
+		var candidates []Protocol
+		for _, p := range undeclared {
+			if p.Type == protocol.Type && !moved[p] {
+				candidates = append(candidates, p)
+			}
+		}
+		matchesProtocol := func(item *yaml.Node) bool {
+			return nodeValue(item, "type") == protocol.Type && nodeValue(item, "path") == protocol.Path
+		}
+		if len(candidates) == 1 {
+			newPath := candidates[0].Path
+			moved[candidates[0]] = true
+			drifts = append(drifts, ManifestDrift{
+				Message: fmt.Sprintf("protocols: the %v path '%v' does not exist, the definitions are in '%v'", protocol.Type, protocol.Path, newPath),
+				Fix:     fmt.Sprintf("set the path to '%v'", newPath),
+				apply: func(root *yaml.Node) {
+					for _, item := range sequenceItems(findNode(root, "protocols")) {
+						if matchesProtocol(item) {
+							setNodeValue(item, []string{"path"}, scalarNode(newPath))
+						}
+					}
+				},
+			})
+			continue
+		}
+		drifts = append(drifts, ManifestDrift{
+			Message: fmt.Sprintf("protocols: the %v path '%v' does not exist", protocol.Type, protocol.Path),
+			Fix:     "remove the protocol",
+			apply: func(root *yaml.Node) {
+				if n := findNode(root, "protocols"); n != nil {
+					removeSequenceItems(n, matchesProtocol)
+				}
+			},
+		})
+	}
+	for _, p := range undeclared {
+		protocol := p
+		if moved[protocol] {
+			continue
+		}
+		drifts = append(drifts, ManifestDrift{
+			Message: fmt.Sprintf("protocols: the %v definitions in '%v' are not declared", protocol.Type, protocol.Path),
+			Fix:     "add the protocol",
+			apply: func(root *yaml.Node) {
+				item := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+				setNodeValue(item, []string{"type"}, scalarNode(protocol.Type))
+				setNodeValue(item, []string{"path"}, scalarNode(protocol.Path))
+				n := sequenceNode(root, "protocols")
+				n.Content = append(n.Content, item)
+			},
+		})
+	}
+	return drifts
+}
+
+// FixManifestDrift rewrites the manifest with the fixes, the comments are preserved.
+func FixManifestDrift(repoDir string, drifts []ManifestDrift) error {
+	manifestPath, err := getManifestPath(repoDir)
+	if err != nil {
+		return err
+	}
+	data, err := ioutil.ReadFile(manifestPath)
+	if err != nil {
+		return err
+	}
+	doc := &yaml.Node{}
+	if err = yaml.Unmarshal(data, doc); err != nil {
+		return err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return fmt.Errorf("%v must contain a mapping", manifestPath)
+	}
+	for _, d := range drifts {
+		d.apply(doc.Content[0])
+	}
+	updated, err := encodeYAMLDocument(doc)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(manifestPath, updated, 0644)
+}
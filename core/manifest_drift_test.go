@@ -0,0 +1,73 @@
+package core
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestManifestDrift(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bub")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	writeFiles(t, dir, map[string]string{
+		".bench.yml": `---
+name: billing
+language: scala # the main language
+types: [service]
+dependencies:
+  - name: postgres # main database
+    version: "9.6"
+protocols:
+  - type: openapi
+    path: api/openapi.yaml
+  - type: raml
+    path: client/src/main/raml
+`,
+		"go.mod": "module billing\n",
+		"docker-compose.yml": `services:
+  db:
+    image: postgres:10.4-alpine
+  cache:
+    image: redis:4
+`,
+		"docs/openapi.yaml": "openapi: 3.0.0\n",
+		// the protocols of the nested manifests are not part of the root manifest.
+		"services/search/.bench.yml":       "name: search\n",
+		"services/search/api/search.proto": "syntax = \"proto3\";\n",
+	})
+
+	drifts, err := CheckManifestDrift(dir)
+	assert.NoError(t, err)
+	var messages []string
+	for _, d := range drifts {
+		messages = append(messages, d.Message)
+	}
+	assert.Equal(t, []string{
+		"languages: 'go' is used in the repository but not declared",
+		"languages: 'scala' is declared but not found in the repository",
+		"dependencies: 'cache' (redis) is in docker-compose.yml but not declared",
+		"dependencies: 'postgres' is declared as version 9.6 but docker-compose.yml uses 10.4-alpine",
+		"protocols: the openapi path 'api/openapi.yaml' does not exist, the definitions are in 'docs/openapi.yaml'",
+		"protocols: the raml path 'client/src/main/raml' does not exist",
+	}, messages)
+
+	assert.NoError(t, FixManifestDrift(dir, drifts))
+	data, err := ioutil.ReadFile(path.Join(dir, ".bench.yml"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "- name: postgres # main database")
+	drifts, err = CheckManifestDrift(dir)
+	assert.NoError(t, err)
+	assert.Empty(t, drifts)
+
+	m, err := ParseManifest("billing", data)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"go"}, m.Languages)
+	assert.Equal(t, []Dependency{
+		{Name: "postgres", Version: "10.4-alpine"},
+		{Name: "cache", Version: "4", Type: "cache"},
+	}, m.Dependencies)
+	assert.Equal(t, []Protocol{{Type: "openapi", Path: "docs/openapi.yaml"}}, m.Protocols)
+}
@@ -0,0 +1,265 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// GraphFormats are the output formats of WriteGraph.
+var GraphFormats = []string{"dot", "mermaid", "json"}
+
+type GraphNode struct {
+	Name string `json:"name"`
+	// Service is true if the node has a manifest.
+	Service  bool   `json:"service"`
+	Type     string `json:"type,omitempty"`
+	External bool   `json:"external,omitempty"`
+	// dependency is the name used in the manifests, e.g. mysql for mainapp-mysql.
+	dependency string
+}
+
+// GraphEdge means that From depends on To, e.g. a service calling a database.
+type GraphEdge struct {
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Implicit  bool   `json:"implicit,omitempty"`
+	External  bool   `json:"external,omitempty"`
+	Dedicated bool   `json:"dedicated,omitempty"`
+	// Both is true if the dependency goes both ways.
+	Both bool `json:"both,omitempty"`
+}
+
+type Graph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// dependencyNodeName returns the unique name of the dependency, the dedicated dependencies
+// are named after the service, e.g. mainapp-mysql.
+func dependencyNodeName(m Manifest, d Dependency) string {
+	if d.UniqueName != "" {
+		return d.UniqueName
+	}
+	if d.Dedicated {
+		return m.Name + "-" + d.Name
+	}
+	return d.Name
+}
+
+// BuildGraph builds the dependency graph of the manifests.
+// The inbound dependencies (direction: in) are reversed, they depend on the service.
+func BuildGraph(manifests Manifests) *Graph {
+	manifests = append(Manifests{}, manifests...)
+	sort.Sort(manifests)
+	nodes := map[string]*GraphNode{}
+	for _, m := range manifests {
+		nodes[m.Name] = &GraphNode{Name: m.Name, Service: true, Type: strings.Join(m.Types, ",")}
+	}
+	g := &Graph{}
+	for _, m := range manifests {
+		for _, d := range m.Dependencies {
+			name := dependencyNodeName(m, d)
+			if _, ok := nodes[name]; !ok {
+				nodes[name] = &GraphNode{Name: name, Type: d.Type, dependency: d.Name}
+			}
+			nodes[name].External = nodes[name].External || d.External
+			e := GraphEdge{From: m.Name, To: name, Implicit: d.Implicit, External: d.External, Dedicated: d.Dedicated}
+			switch d.Direction {
+			case "in":
+				e.From, e.To = e.To, e.From
+			case "both":
+				e.Both = true
+			}
+			g.Edges = append(g.Edges, e)
+		}
+	}
+	for _, n := range nodes {
+		g.Nodes = append(g.Nodes, *n)
+	}
+	sort.Slice(g.Nodes, func(i, j int) bool { return g.Nodes[i].Name < g.Nodes[j].Name })
+	sort.SliceStable(g.Edges, func(i, j int) bool {
+		if g.Edges[i].From != g.Edges[j].From {
+			return g.Edges[i].From < g.Edges[j].From
+		}
+		return g.Edges[i].To < g.Edges[j].To
+	})
+	return g
+}
+
+// Focus returns the subgraph containing the node, its dependencies and its dependents.
+func (g *Graph) Focus(name string) (*Graph, error) {
+	focused := &Graph{}
+	names := map[string]bool{name: true}
+	for _, e := range g.Edges {
+		if e.From == name || e.To == name {
+			focused.Edges = append(focused.Edges, e)
+			names[e.From], names[e.To] = true, true
+		}
+	}
+	for _, n := range g.Nodes {
+		if names[n.Name] {
+			focused.Nodes = append(focused.Nodes, n)
+		}
+	}
+	if len(focused.Nodes) == 0 {
+		return nil, fmt.Errorf("'%v' is not in the graph", name)
+	}
+	return focused, nil
+}
+
+// Dependent is impacted when the dependency goes down, through the path.
+type Dependent struct {
+	Name string `json:"name"`
+	// Path from the dependent to the dependency, e.g. [mainapp billing postgres]
+	Path []string `json:"path"`
+	// Implicit is true if one of the dependencies of the path is implicit.
+	Implicit bool `json:"implicit"`
+}
+
+// Dependents returns every node depending directly or transitively on the node,
+// i.e. what breaks if it goes down. The name can be the name of a dependency shared by
+// several dedicated nodes, e.g. postgres matches mainapp-postgres.
+func (g *Graph) Dependents(name string) ([]Dependent, error) {
+	dependents := map[string][]GraphEdge{}
+	var targets []string
+	for _, n := range g.Nodes {
+		if n.Name == name || n.dependency == name {
+			targets = append(targets, n.Name)
+		}
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("'%v' is not in the graph", name)
+	}
+	for _, e := range g.Edges {
+		dependents[e.To] = append(dependents[e.To], e)
+		if e.Both {
+			reversed := e
+			reversed.From, reversed.To = e.To, e.From
+			dependents[e.From] = append(dependents[e.From], reversed)
+		}
+	}
+
+	// breadth first, to get the shortest path of each dependent.
+	visited := map[string]bool{}
+	var queue []Dependent
+	for _, t := range targets {
+		visited[t] = true
+		queue = append(queue, Dependent{Name: t, Path: []string{t}})
+	}
+	var result []Dependent
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, e := range dependents[current.Name] {
+			if visited[e.From] {
+				continue
+			}
+			visited[e.From] = true
+			d := Dependent{
+				Name:     e.From,
+				Path:     append([]string{e.From}, current.Path...),
+				Implicit: current.Implicit || e.Implicit,
+			}
+			result = append(result, d)
+			queue = append(queue, d)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return len(result[i].Path) < len(result[j].Path) })
+	return result, nil
+}
+
+// WriteGraph writes the graph as Graphviz DOT, Mermaid or JSON.
+// The implicit dependencies are dashed and the external ones dotted.
+func WriteGraph(w io.Writer, g *Graph, format string) error {
+	switch format {
+	case "dot", "":
+		return writeDOT(w, g)
+	case "mermaid":
+		return writeMermaid(w, g)
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(g)
+	}
+	return fmt.Errorf("unknown format '%v', must be one of: %v", format, strings.Join(GraphFormats, ", "))
+}
+
+func writeDOT(w io.Writer, g *Graph) error {
+	fmt.Fprintln(w, "digraph dependencies {")
+	fmt.Fprintln(w, "  rankdir=LR;")
+	for _, n := range g.Nodes {
+		var attrs []string
+		if n.Service {
+			attrs = append(attrs, "shape=box")
+		} else {
+			attrs = append(attrs, "shape=ellipse")
+		}
+		if n.External {
+			attrs = append(attrs, "style=dashed")
+		}
+		fmt.Fprintf(w, "  %q [%v];\n", n.Name, strings.Join(attrs, ", "))
+	}
+	for _, e := range g.Edges {
+		var attrs []string
+		if e.Implicit {
+			attrs = append(attrs, "style=dashed")
+		} else if e.External {
+			attrs = append(attrs, "style=dotted")
+		}
+		if e.Both {
+			attrs = append(attrs, "dir=both")
+		}
+		suffix := ""
+		if len(attrs) > 0 {
+			suffix = " [" + strings.Join(attrs, ", ") + "]"
+		}
+		fmt.Fprintf(w, "  %q -> %q%v;\n", e.From, e.To, suffix)
+	}
+	_, err := fmt.Fprintln(w, "}")
+	return err
+}
+
+var mermaidIdRegex = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+func mermaidId(name string) string {
+	return mermaidIdRegex.ReplaceAllString(name, "_")
+}
+
+func writeMermaid(w io.Writer, g *Graph) error {
+	fmt.Fprintln(w, "graph LR")
+	for _, n := range g.Nodes {
+		if n.Service {
+			fmt.Fprintf(w, "  %v[\"%v\"]\n", mermaidId(n.Name), n.Name)
+		} else {
+			fmt.Fprintf(w, "  %v([\"%v\"])\n", mermaidId(n.Name), n.Name)
+		}
+	}
+	for _, e := range g.Edges {
+		arrow := "-->"
+		if e.Implicit {
+			arrow = "-.->"
+		}
+		if e.Both {
+			arrow = "<" + arrow
+		}
+		if e.External && !e.Implicit {
+			arrow += "|external|"
+		}
+		fmt.Fprintf(w, "  %v %v %v\n", mermaidId(e.From), arrow, mermaidId(e.To))
+	}
+	var external []string
+	for _, n := range g.Nodes {
+		if n.External {
+			external = append(external, mermaidId(n.Name))
+		}
+	}
+	if len(external) > 0 {
+		fmt.Fprintln(w, "  classDef external stroke-dasharray: 5 5")
+		fmt.Fprintf(w, "  class %v external\n", strings.Join(external, ","))
+	}
+	return nil
+}
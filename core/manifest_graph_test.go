@@ -0,0 +1,64 @@
+package core
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestBuildGraph(t *testing.T) {
+	g := BuildGraph(testManifests(t))
+	var names []string
+	for _, n := range g.Nodes {
+		names = append(names, n.Name)
+	}
+	assert.Equal(t, []string{"billing", "frontend", "mainapp", "postgres"}, names)
+	assert.Equal(t, []GraphEdge{
+		{From: "billing", To: "postgres", External: true},
+		{From: "frontend", To: "mainapp", Implicit: true},
+		{From: "mainapp", To: "billing"},
+		{From: "mainapp", To: "postgres"},
+	}, g.Edges)
+
+	focused, err := g.Focus("billing")
+	assert.NoError(t, err)
+	assert.Len(t, focused.Nodes, 3)
+	assert.Len(t, focused.Edges, 2)
+	_, err = g.Focus("unknown")
+	assert.Error(t, err)
+}
+
+func TestGraphDependents(t *testing.T) {
+	g := BuildGraph(testManifests(t))
+	dependents, err := g.Dependents("postgres")
+	assert.NoError(t, err)
+	assert.Equal(t, []Dependent{
+		{Name: "billing", Path: []string{"billing", "postgres"}},
+		{Name: "mainapp", Path: []string{"mainapp", "postgres"}},
+		{Name: "frontend", Path: []string{"frontend", "mainapp", "postgres"}, Implicit: true},
+	}, dependents)
+
+	m, err := ParseManifest("search", []byte("name: search\ndependencies:\n  - name: elasticsearch\n    dedicated: true\n  - name: mainapp\n    direction: in\n"))
+	assert.NoError(t, err)
+	g = BuildGraph(Manifests{*m})
+	dependents, err = g.Dependents("elasticsearch")
+	assert.NoError(t, err)
+	assert.Equal(t, []Dependent{
+		{Name: "search", Path: []string{"search", "search-elasticsearch"}},
+		{Name: "mainapp", Path: []string{"mainapp", "search", "search-elasticsearch"}},
+	}, dependents)
+}
+
+func TestWriteGraph(t *testing.T) {
+	g := BuildGraph(testManifests(t))
+	var buf bytes.Buffer
+	assert.NoError(t, WriteGraph(&buf, g, "dot"))
+	assert.Contains(t, buf.String(), `"frontend" -> "mainapp" [style=dashed];`)
+	assert.Contains(t, buf.String(), `"billing" -> "postgres" [style=dotted];`)
+
+	buf.Reset()
+	assert.NoError(t, WriteGraph(&buf, g, "mermaid"))
+	assert.Contains(t, buf.String(), "frontend -.-> mainapp")
+	assert.Contains(t, buf.String(), "billing -->|external| postgres")
+	assert.Error(t, WriteGraph(&buf, g, "png"))
+}
@@ -0,0 +1,66 @@
+package core
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+)
+
+// writeFiles writes the files in the directory, the paths are relative to the directory.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		assert.NoError(t, os.MkdirAll(path.Dir(path.Join(dir, name)), 0700))
+		assert.NoError(t, ioutil.WriteFile(path.Join(dir, name), []byte(content), 0600))
+	}
+}
+
+func TestFindManifests(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bub")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	dir, _ = filepath.EvalSymlinks(dir)
+	writeFiles(t, dir, map[string]string{
+		".bench.yml":                         "name: platform\ntypes: [library]\n",
+		"services/billing/.bench.yml":        "types: [service]\n",
+		"services/billing/src/main.go":       "package main\n",
+		"services/search/manifest.yml":       "name: search-api\n",
+		"services/search/node_modules/x.yml": "",
+		"node_modules/dep/.bench.yml":        "name: ignored\n",
+		".git/.bench.yml":                    "name: ignored\n",
+	})
+
+	manifests, err := FindManifests(dir, "platform-repo")
+	assert.NoError(t, err)
+	var names, dirs []string
+	for _, m := range manifests {
+		assert.Equal(t, "platform-repo", m.Repository)
+		names = append(names, m.Name)
+		dirs = append(dirs, m.Dir)
+	}
+	assert.Equal(t, []string{"platform", "billing", "search-api"}, names)
+	assert.Equal(t, []string{"", "services/billing", "services/search"}, dirs)
+
+	found, err := FindManifestDir(dir, path.Join(dir, "services/billing/src"))
+	assert.NoError(t, err)
+	assert.Equal(t, path.Join(dir, "services/billing"), found)
+	found, err = FindManifestDir(dir, path.Join(dir, "services"))
+	assert.NoError(t, err)
+	assert.Equal(t, dir, found)
+}
+
+func TestSelectManifestPaths(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, []string{".bench.yml", "services/billing/.bench.yml", "services/search/manifest.yml"}, SelectManifestPaths([]string{
+		".bench.yml",
+		"services/billing/.bench.yml",
+		"services/billing/manifest.yml",
+		"services/billing/main.go",
+		"services/search/manifest.yml",
+		"services/search/node_modules/dep/.bench.yml",
+		".github/.bench.yml",
+	}))
+	assert.Empty(t, SelectManifestPaths([]string{"README.md"}))
+}
@@ -0,0 +1,144 @@
+package core
+
+import (
+	"fmt"
+	"github.com/j-martin/bub/utils"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	// DependencyDirections are the values allowed in dependencies[].direction, out is the default.
+	DependencyDirections = []string{"in", "out", "both"}
+	// DependencyTypes are the values allowed in dependencies[].type.
+	DependencyTypes = []string{"service", "database", "front-end", "queue", "cache", "storage"}
+)
+
+// getManifestPath returns the manifest of the repository, .bench.yml or the legacy manifest.yml.
+func getManifestPath(repoDir string) (string, error) {
+	for _, name := range manifestFileNames {
+		manifestPath := path.Join(repoDir, name)
+		exists, err := utils.PathExists(manifestPath)
+		if err != nil || exists {
+			return manifestPath, err
+		}
+	}
+	return "", fmt.Errorf("no %v found in %v, run 'bub manifest create'", manifestFile, repoDir)
+}
+
+// ValidateManifest validates the manifest of the repository, see docs/manifest.schema.json.
+func ValidateManifest(repoDir string) (ValidationErrors, error) {
+	manifestPath, err := getManifestPath(repoDir)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	return validateManifestData(manifestPath, repoDir, data), nil
+}
+
+// ValidateRepositoryManifests validates every manifest of the repository, including the nested ones.
+func ValidateRepositoryManifests(repoDir string) (ValidationErrors, error) {
+	manifestPaths, err := findManifestPaths(repoDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(manifestPaths) == 0 {
+		return nil, fmt.Errorf("no %v found in %v, run 'bub manifest create'", manifestFile, repoDir)
+	}
+	var errs ValidationErrors
+	for _, manifestPath := range manifestPaths {
+		data, err := ioutil.ReadFile(manifestPath)
+		if err != nil {
+			return nil, err
+		}
+		errs = append(errs, validateManifestData(manifestPath, path.Dir(manifestPath), data)...)
+	}
+	return errs, nil
+}
+
+func validateManifestData(file, repoDir string, data []byte) ValidationErrors {
+	root, errs := parseYAMLDocument(file, data)
+	if root == nil {
+		if len(errs) == 0 {
+			errs = ValidationErrors{{File: file, Line: 1, Message: "the manifest is empty"}}
+		}
+		return errs
+	}
+	if err := root.Decode(&Manifest{}); err != nil {
+		errs = append(errs, yamlErrors(file, err)...)
+	}
+	errs = append(errs, checkUnknownKeys(file, "", root, reflect.TypeOf(Manifest{}))...)
+	newError := func(n *yaml.Node, format string, args ...interface{}) {
+		line := root.Line
+		if n != nil {
+			line = n.Line
+		}
+		errs = append(errs, ValidationError{File: file, Line: line, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if isEmptyNode(findNode(root, "name")) {
+		newError(findNode(root, "name"), "name is required")
+	}
+	if isEmptyNode(findNode(root, "languages")) && isEmptyNode(findNode(root, "language")) {
+		newError(nil, "languages is required, e.g. [go]")
+	}
+	if isEmptyNode(findNode(root, "types")) {
+		newError(nil, "types is required, e.g. [service]")
+	}
+	if page := findNode(root, "page"); page != nil {
+		newError(page, "page is deprecated, use documentation.pageId")
+	}
+
+	for i, dep := range sequenceItems(findNode(root, "dependencies")) {
+		if isEmptyNode(findNode(dep, "name")) {
+			newError(dep, "dependencies[%v].name is required", i)
+		}
+		if direction := findNode(dep, "direction"); !isEmptyNode(direction) && !utils.Contains(direction.Value, DependencyDirections...) {
+			newError(direction, "dependencies[%v].direction '%v' must be one of: %v",
+				i, direction.Value, strings.Join(DependencyDirections, ", "))
+		}
+		if depType := findNode(dep, "type"); !isEmptyNode(depType) && !utils.Contains(depType.Value, DependencyTypes...) {
+			newError(depType, "dependencies[%v].type '%v' must be one of: %v",
+				i, depType.Value, strings.Join(DependencyTypes, ", "))
+		}
+	}
+
+	for i, protocol := range sequenceItems(findNode(root, "protocols")) {
+		if isEmptyNode(findNode(protocol, "type")) {
+			newError(protocol, "protocols[%v].type is required", i)
+		}
+		protocolPath := findNode(protocol, "path")
+		if isEmptyNode(protocolPath) {
+			newError(protocol, "protocols[%v].path is required", i)
+		} else if _, err := os.Stat(path.Join(repoDir, protocolPath.Value)); err != nil {
+			newError(protocolPath, "protocols[%v].path '%v' does not exist", i, protocolPath.Value)
+		}
+	}
+
+	if pageId := findNode(root, "documentation", "pageId"); !isEmptyNode(pageId) {
+		if _, err := strconv.ParseUint(pageId.Value, 10, 64); err != nil {
+			newError(pageId, "documentation.pageId must be the numeric id of the Confluence page, got '%v'", pageId.Value)
+		}
+	}
+	for _, dir := range sequenceItems(findNode(root, "documentation", "ignoredDirs")) {
+		if info, err := os.Stat(path.Join(repoDir, dir.Value)); err != nil || !info.IsDir() {
+			newError(dir, "documentation.ignoredDirs '%v' is not a directory", dir.Value)
+		}
+	}
+	return errs
+}
+
+func sequenceItems(n *yaml.Node) []*yaml.Node {
+	if n == nil || n.Kind != yaml.SequenceNode {
+		return nil
+	}
+	return n.Content
+}
@@ -0,0 +1,61 @@
+package core
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"testing"
+)
+
+func TestValidateManifestData(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bub")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	assert.NoError(t, os.MkdirAll(path.Join(dir, "api"), 0700))
+
+	content := `---
+name: mainapp
+languages:
+  - go
+dependencies:
+  - name: postgres
+    type: database
+  - name: activemq
+    direction: inbound
+    type: broker
+  - direction: out
+protocols:
+  - type: openapi
+    path: api
+  - type: raml
+    path: client/src/main/raml
+documentation:
+  pageId: pageID from confluence
+  ignoredDirs:
+    - api
+    - vendor
+`
+	errs := validateManifestData(".bench.yml", dir, []byte(content))
+	sort.Stable(errs)
+	assert.Equal(t, ValidationErrors{
+		{File: ".bench.yml", Line: 2, Message: "types is required, e.g. [service]"},
+		{File: ".bench.yml", Line: 9, Message: "dependencies[1].direction 'inbound' must be one of: in, out, both"},
+		{File: ".bench.yml", Line: 10, Message: "dependencies[1].type 'broker' must be one of: service, database, front-end, queue, cache, storage"},
+		{File: ".bench.yml", Line: 11, Message: "dependencies[2].name is required"},
+		{File: ".bench.yml", Line: 16, Message: "protocols[1].path 'client/src/main/raml' does not exist"},
+		{File: ".bench.yml", Line: 18, Message: "documentation.pageId must be the numeric id of the Confluence page, got 'pageID from confluence'"},
+		{File: ".bench.yml", Line: 21, Message: "documentation.ignoredDirs 'vendor' is not a directory"},
+	}, errs)
+}
+
+func TestValidateManifestDataUnknownKeys(t *testing.T) {
+	errs := validateManifestData(".bench.yml", ".", []byte("name: mainapp\nlanguages: [go]\ntypes: [service]\nowner: someone\npage: '123'\nrepository: mainapp\n"))
+	sort.Stable(errs)
+	assert.Equal(t, ValidationErrors{
+		{File: ".bench.yml", Line: 4, Message: "unknown key 'owner'"},
+		{File: ".bench.yml", Line: 5, Message: "page is deprecated, use documentation.pageId"},
+		{File: ".bench.yml", Line: 6, Message: "unknown key 'repository'"},
+	}, errs)
+}
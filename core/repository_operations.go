@@ -0,0 +1,183 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// RepoOperation is applied to a repository. The git commands must run within the context (see Git.WithContext)
+// to be stopped when the operation times out.
+type RepoOperation func(ctx context.Context, repo string) (string, error)
+
+// ConcurrencyOptions limits the operations running on the repositories.
+type ConcurrencyOptions struct {
+	// Jobs is the number of repositories processed at once.
+	Jobs int
+	// Timeout of the operation of each repository, none if 0.
+	Timeout time.Duration
+}
+
+type RepositoryStatus string
+
+const (
+	RepositoryDone        RepositoryStatus = "done"
+	RepositoryFailed      RepositoryStatus = "failed"
+	RepositoryTimedOut    RepositoryStatus = "timed out"
+	RepositoryInterrupted RepositoryStatus = "interrupted"
+	// RepositorySkipped is not started since the operations were interrupted.
+	RepositorySkipped RepositoryStatus = "skipped"
+)
+
+type ConcurrentResult struct {
+	Repository string
+	Status     RepositoryStatus
+	Output     string
+	Err        error
+	Duration   time.Duration
+}
+
+type ConcurrentResults []ConcurrentResult
+
+// ConcurrentRepositoryOperations applies the operation to the repositories with a pool of workers. On Ctrl-C,
+// the running operations finish but no other is started, a second Ctrl-C stops them. The outputs and a summary
+// are printed once every operation is done.
+func ConcurrentRepositoryOperations(repos []string, opts ConcurrencyOptions, fn RepoOperation) error {
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer signal.Stop(interrupts)
+
+	results := runRepositoryOperations(repos, opts, fn, interrupts, os.Stderr)
+	if err := WriteConcurrentResults(os.Stdout, results); err != nil {
+		return err
+	}
+	errorCount := 0
+	for _, r := range results {
+		if r.Status != RepositoryDone {
+			errorCount++
+		}
+	}
+	if errorCount > 0 {
+		log.Printf("%v repos failed to be updated.", errorCount)
+		return errors.New("some repos failed to update")
+	}
+	log.Print("All Done.")
+	return nil
+}
+
+// runRepositoryOperations runs the operations and writes the progress. The results are in the order of the repos.
+func runRepositoryOperations(repos []string, opts ConcurrencyOptions, fn RepoOperation, interrupts <-chan os.Signal, progress io.Writer) ConcurrentResults {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	results := make(ConcurrentResults, len(repos))
+	for i, r := range repos {
+		results[i] = ConcurrentResult{Repository: r, Status: RepositorySkipped}
+	}
+
+	queue := make(chan int)
+	done := make(chan int)
+	jobs := opts.Jobs
+	if jobs < 1 {
+		jobs = 1
+	}
+	var wg sync.WaitGroup
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				results[i] = runRepositoryOperation(ctx, repos[i], opts.Timeout, fn)
+				done <- i
+			}
+		}()
+	}
+
+	next, running, finished, failed := 0, 0, 0, 0
+	interrupted := false
+	for (next < len(repos) && !interrupted) || running > 0 {
+		var send chan int
+		if next < len(repos) && !interrupted {
+			send = queue
+		}
+		select {
+		case send <- next:
+			next++
+			running++
+		case i := <-done:
+			running--
+			finished++
+			if results[i].Status != RepositoryDone {
+				failed++
+			}
+		case <-interrupts:
+			if interrupted {
+				fmt.Fprintf(progress, "\nInterrupted, stopping the %v running operations.\n", running)
+				cancel()
+			} else {
+				interrupted = true
+				fmt.Fprintf(progress, "\nInterrupted, waiting for the %v running operations, Ctrl-C again to stop them.\n", running)
+			}
+		}
+		fmt.Fprintf(progress, "\r[%v/%v] %v running, %v failed", finished, len(repos), running, failed)
+	}
+	close(queue)
+	wg.Wait()
+	fmt.Fprintln(progress)
+	return results
+}
+
+func runRepositoryOperation(parent context.Context, repo string, timeout time.Duration, fn RepoOperation) ConcurrentResult {
+	ctx := parent
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(parent, timeout)
+		defer cancel()
+	}
+	start := time.Now()
+	output, err := fn(ctx, repo)
+	result := ConcurrentResult{Repository: repo, Status: RepositoryDone, Output: output, Err: err, Duration: time.Since(start)}
+	switch {
+	case err == nil:
+	case ctx.Err() == context.DeadlineExceeded:
+		result.Status, result.Err = RepositoryTimedOut, fmt.Errorf("timed out after %v", timeout)
+	case ctx.Err() == context.Canceled:
+		result.Status = RepositoryInterrupted
+	default:
+		result.Status = RepositoryFailed
+	}
+	return result
+}
+
+// WriteConcurrentResults writes the outputs and the summary of the operations, sorted by repository.
+func WriteConcurrentResults(w io.Writer, results ConcurrentResults) error {
+	sorted := append(ConcurrentResults{}, results...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Repository < sorted[j].Repository
+	})
+	for _, r := range sorted {
+		if r.Output != "" {
+			fmt.Fprintln(w, r.Output)
+		}
+	}
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "Repository\tStatus\tDuration\tError")
+	for _, r := range sorted {
+		duration, message := "-", ""
+		if r.Status != RepositorySkipped {
+			duration = r.Duration.Round(100 * time.Millisecond).String()
+		}
+		if r.Err != nil {
+			message = r.Err.Error()
+		}
+		fmt.Fprintf(table, "%v\t%v\t%v\t%v\n", r.Repository, r.Status, duration, message)
+	}
+	return table.Flush()
+}
@@ -0,0 +1,102 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/j-martin/bub/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunRepositoryOperationsJobs(t *testing.T) {
+	t.Parallel()
+	var mutex sync.Mutex
+	running, maxRunning := 0, 0
+	repos := []string{"a", "b", "c", "d", "e"}
+	results := runRepositoryOperations(repos, ConcurrencyOptions{Jobs: 2}, func(ctx context.Context, repo string) (string, error) {
+		mutex.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mutex.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		mutex.Lock()
+		running--
+		mutex.Unlock()
+		if repo == "c" {
+			return "", errors.New("conflict")
+		}
+		return repo + " updated", nil
+	}, nil, ioutil.Discard)
+	assert.Equal(t, 2, maxRunning)
+	assert.Len(t, results, 5)
+	for i, r := range results {
+		assert.Equal(t, repos[i], r.Repository)
+	}
+	assert.Equal(t, RepositoryFailed, results[2].Status)
+	assert.Equal(t, RepositoryDone, results[4].Status)
+	assert.Equal(t, "e updated", results[4].Output)
+}
+
+func TestRunRepositoryOperationsTimeout(t *testing.T) {
+	t.Parallel()
+	start := time.Now()
+	results := runRepositoryOperations([]string{"slow"}, ConcurrencyOptions{Jobs: 1, Timeout: 100 * time.Millisecond}, func(ctx context.Context, repo string) (string, error) {
+		return utils.RunCmdWithFullOutputContext(ctx, "sleep", "5")
+	}, nil, ioutil.Discard)
+	assert.True(t, time.Since(start) < 2*time.Second)
+	assert.Equal(t, RepositoryTimedOut, results[0].Status)
+	assert.EqualError(t, results[0].Err, "timed out after 100ms")
+}
+
+func TestRunRepositoryOperationsInterrupt(t *testing.T) {
+	t.Parallel()
+	interrupts := make(chan os.Signal)
+	results := runRepositoryOperations([]string{"a", "b", "c"}, ConcurrencyOptions{Jobs: 1}, func(ctx context.Context, repo string) (string, error) {
+		// the running operation finishes, the others are skipped.
+		interrupts <- os.Interrupt
+		return "", nil
+	}, interrupts, ioutil.Discard)
+	assert.Equal(t, RepositoryDone, results[0].Status)
+	assert.Equal(t, RepositorySkipped, results[1].Status)
+	assert.Equal(t, RepositorySkipped, results[2].Status)
+}
+
+func TestWriteConcurrentResults(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	err := WriteConcurrentResults(&buf, ConcurrentResults{
+		{Repository: "web", Status: RepositorySkipped},
+		{Repository: "billing", Status: RepositoryFailed, Output: "pull\nconflict", Err: errors.New("exit status 1"), Duration: 1234 * time.Millisecond},
+		{Repository: "api", Status: RepositoryDone, Duration: 2 * time.Second},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Join([]string{
+		"pull",
+		"conflict",
+		"Repository  Status   Duration  Error",
+		"api         done     2s        ",
+		"billing     failed   1.2s      exit status 1",
+		"web         skipped  -         ",
+		"",
+	}, "\n"), buf.String())
+}
+
+func TestRunRepositoryOperationsNonInteractive(t *testing.T) {
+	t.Parallel()
+	results := runRepositoryOperations([]string{"a"}, ConcurrencyOptions{Jobs: 1}, func(ctx context.Context, repo string) (string, error) {
+		return utils.RunCmdWithFullOutputContext(ctx, "sh", "-c", "echo $GIT_TERMINAL_PROMPT; echo $GIT_SSH_COMMAND")
+	}, nil, ioutil.Discard)
+	assert.Equal(t, RepositoryDone, results[0].Status)
+	lines := strings.Split(results[0].Output, "\n")
+	assert.Equal(t, "0", lines[1])
+	assert.True(t, strings.HasSuffix(lines[2], " -o BatchMode=yes"), lines[2])
+}
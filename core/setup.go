@@ -0,0 +1,97 @@
+package core
+
+import (
+	"fmt"
+	"github.com/j-martin/bub/utils"
+	"gopkg.in/yaml.v3"
+	"io"
+	"os"
+	"strings"
+)
+
+// Integrations lists the integrations that can be enabled with 'bub setup'.
+var Integrations = []string{"jira", "confluence", "github", "jenkins", "vault"}
+
+// SetupAnswers are the answers used by 'bub setup --non-interactive'.
+type SetupAnswers struct {
+	// integrations to enable, the others are disabled.
+	Enable []string
+	// config values, e.g. jira.server: https://example.atlassian.net
+	Config map[string]string
+	// credentials per item, e.g. GitHub Token: <token>. Run 'bub credentials list' to see the items.
+	Credentials map[string]string
+}
+
+func LoadSetupAnswers(answersFile string) (*SetupAnswers, error) {
+	answers := &SetupAnswers{}
+	if answersFile == "" {
+		return answers, nil
+	}
+	file, err := os.Open(answersFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err = decoder.Decode(answers); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to parse %v: %v", answersFile, err)
+	}
+	return answers, CheckIntegrations(answers.Enable)
+}
+
+// CheckIntegrations returns an error if one of the integrations is unknown.
+func CheckIntegrations(integrations []string) error {
+	for _, i := range integrations {
+		if !utils.Contains(i, Integrations...) {
+			return fmt.Errorf("unknown integration '%v', must be one of: %v", i, strings.Join(Integrations, ", "))
+		}
+	}
+	return nil
+}
+
+// IsIntegrationEnabled returns false only if <integration>.enabled is false, the integrations of the
+// configs created before 'bub setup' recorded the flag are enabled.
+func (cfg *Configuration) IsIntegrationEnabled(integration string) bool {
+	f, err := findConfigField(cfg, integration+".enabled")
+	return err == nil && (f.Value.IsNil() || f.Value.Elem().Bool())
+}
+
+// SetIntegrations records the enabled flag of every integration in the user config.
+func SetIntegrations(enabled []string) error {
+	values := make(map[string]string)
+	for _, i := range Integrations {
+		values[i+".enabled"] = fmt.Sprint(utils.Contains(i, enabled...))
+	}
+	return SetConfigValues(ConfigUserFile, values)
+}
+
+// StoreSetupCredentials stores the credentials of the integration taken from the answers,
+// or from the environment variables (e.g. JIRA_PASSWORD) when they are not in the answers.
+func (cfg *Configuration) StoreSetupCredentials(integration string, answers *SetupAnswers) error {
+	var missing []string
+	for _, item := range cfg.CredentialItems() {
+		if !strings.HasPrefix(item.Path, integration+".") {
+			continue
+		}
+		value, ok := answers.Credentials[item.Name]
+		if !ok {
+			value, ok = os.LookupEnv(credentialEnvName(item.Name))
+		}
+		if !ok {
+			if _, err := cfg.PeekCredential(item.Name); err == nil {
+				// already stored, e.g. when provisioning again.
+				continue
+			}
+			missing = append(missing, fmt.Sprintf("'%v' (%v)", item.Name, credentialEnvName(item.Name)))
+			continue
+		}
+		if err := cfg.StoreCredential(item.Name, value); err != nil {
+			return err
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing credentials for %v: %v", integration, strings.Join(missing, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package core
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ValidationError points to the file and line where the problem was found.
+type ValidationError struct {
+	File    string
+	Line    int
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%v:%v: %v", e.File, e.Line, e.Message)
+}
+
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Len() int {
+	return len(e)
+}
+
+func (e ValidationErrors) Less(i, j int) bool {
+	if e[i].File != e[j].File {
+		return e[i].File < e[j].File
+	}
+	return e[i].Line < e[j].Line
+}
+
+func (e ValidationErrors) Swap(i, j int) {
+	e[i], e[j] = e[j], e[i]
+}
+
+// yamlKey returns the key used by the yaml package for a struct field. e.g. Server -> server
+func yamlKey(f reflect.StructField) string {
+	tag := strings.Split(f.Tag.Get("yaml"), ",")[0]
+	if tag != "" {
+		return tag
+	}
+	return strings.ToLower(f.Name)
+}
+
+// parseYAMLDocument returns the root node of the document, or nil if the document is empty.
+func parseYAMLDocument(file string, data []byte) (*yaml.Node, ValidationErrors) {
+	doc := &yaml.Node{}
+	if err := yaml.Unmarshal(data, doc); err != nil {
+		return nil, yamlErrors(file, err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	return doc.Content[0], nil
+}
+
+var yamlErrorLineRegex = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// yamlErrors converts the errors returned by the yaml package to validation errors.
+func yamlErrors(file string, err error) (errs ValidationErrors) {
+	var messages []string
+	if typeErr, ok := err.(*yaml.TypeError); ok {
+		messages = typeErr.Errors
+	} else {
+		messages = []string{err.Error()}
+	}
+	for _, m := range messages {
+		e := ValidationError{File: file, Message: m}
+		if match := yamlErrorLineRegex.FindStringSubmatch(m); match != nil {
+			e.Line, _ = strconv.Atoi(match[1])
+			e.Message = match[2]
+		}
+		errs = append(errs, e)
+	}
+	return errs
+}
+
+// checkUnknownKeys reports the keys that do not map to a field of the given type.
+func checkUnknownKeys(file, prefix string, n *yaml.Node, t reflect.Type) (errs ValidationErrors) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case n.Kind == yaml.SequenceNode && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array):
+		for i, item := range n.Content {
+			errs = append(errs, checkUnknownKeys(file, fmt.Sprintf("%v[%v]", prefix, i), item, t.Elem())...)
+		}
+	case n.Kind == yaml.MappingNode && t.Kind() == reflect.Map:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			errs = append(errs, checkUnknownKeys(file, joinPath(prefix, n.Content[i].Value), n.Content[i+1], t.Elem())...)
+		}
+	case n.Kind == yaml.MappingNode && t.Kind() == reflect.Struct:
+		fields := map[string]reflect.StructField{}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" || f.Tag.Get("yaml") == "-" {
+				continue
+			}
+			fields[yamlKey(f)] = f
+		}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key := n.Content[i]
+			f, ok := fields[key.Value]
+			if !ok {
+				errs = append(errs, ValidationError{
+					File:    file,
+					Line:    key.Line,
+					Message: fmt.Sprintf("unknown key '%v'", joinPath(prefix, key.Value)),
+				})
+				continue
+			}
+			errs = append(errs, checkUnknownKeys(file, joinPath(prefix, key.Value), n.Content[i+1], f.Type)...)
+		}
+	}
+	return errs
+}
+
+func joinPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// findNode returns the value node at the given path. e.g. findNode(root, "jira", "board")
+func findNode(n *yaml.Node, keys ...string) *yaml.Node {
+	for _, key := range keys {
+		if n == nil || n.Kind != yaml.MappingNode {
+			return nil
+		}
+		var value *yaml.Node
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if n.Content[i].Value == key {
+				value = n.Content[i+1]
+			}
+		}
+		n = value
+	}
+	return n
+}
+
+func isEmptyNode(n *yaml.Node) bool {
+	return n == nil || (n.Kind == yaml.ScalarNode && (n.Tag == "!!null" || n.Value == ""))
+}
@@ -0,0 +1,138 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/j-martin/bub/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// WorkspaceFile lists the repositories of the workspace and their groups, at the root of the workspace.
+const WorkspaceFile = ".bub-workspace.yml"
+
+// Workspace is the directory containing the repositories.
+type Workspace struct {
+	Repositories []string
+	// Groups of repositories, e.g. payments or frontend.
+	Groups map[string][]string
+	dir    string
+}
+
+// WorkspaceFilter selects the repositories of the workspace, the empty fields match everything.
+type WorkspaceFilter struct {
+	Groups, Only, Exclude []string
+	// Manifest must match one of the manifests of the repository, e.g. its type or language.
+	Manifest ManifestFilter
+}
+
+// LoadWorkspace reads the WorkspaceFile of the directory. Without the file, every repository of the
+// directory is part of the workspace.
+func LoadWorkspace(dir string) (*Workspace, error) {
+	filePath := path.Join(dir, WorkspaceFile)
+	file, err := os.Open(filePath)
+	if os.IsNotExist(err) {
+		return listWorkspaceRepositories(dir)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	w := &Workspace{dir: dir}
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err = decoder.Decode(w); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to parse %v: %v", filePath, err)
+	}
+	for group, repos := range w.Groups {
+		for _, r := range repos {
+			if !utils.Contains(r, w.Repositories...) {
+				return nil, fmt.Errorf("%v: the group '%v' contains '%v' which is not in the repositories", filePath, group, r)
+			}
+		}
+	}
+	return w, nil
+}
+
+func listWorkspaceRepositories(dir string) (*Workspace, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	w := &Workspace{dir: dir}
+	for _, f := range files {
+		if f.IsDir() && utils.IsRepository(path.Join(dir, f.Name())) {
+			w.Repositories = append(w.Repositories, f.Name())
+		}
+	}
+	return w, nil
+}
+
+// SelectRepositories returns the directories of the cloned repositories matching the filter, sorted.
+func (w *Workspace) SelectRepositories(f WorkspaceFilter) ([]string, error) {
+	selected := map[string]bool{}
+	if len(f.Groups) == 0 {
+		for _, r := range w.Repositories {
+			selected[r] = true
+		}
+	}
+	for _, g := range f.Groups {
+		repos, ok := w.Groups[g]
+		if !ok {
+			return nil, fmt.Errorf("unknown group '%v', must be one of: %v", g, strings.Join(w.groupNames(), ", "))
+		}
+		for _, r := range repos {
+			selected[r] = true
+		}
+	}
+	for _, r := range append(append([]string{}, f.Only...), f.Exclude...) {
+		if !utils.Contains(r, w.Repositories...) {
+			return nil, fmt.Errorf("'%v' is not a repository of the workspace", r)
+		}
+	}
+	if len(f.Only) > 0 {
+		for r := range selected {
+			if !utils.Contains(r, f.Only...) {
+				delete(selected, r)
+			}
+		}
+	}
+	for _, r := range f.Exclude {
+		delete(selected, r)
+	}
+
+	var repoDirs []string
+	for r := range selected {
+		repoDir := path.Join(w.dir, r)
+		if !utils.IsRepository(repoDir) {
+			log.Printf("Skipping %v, it is not cloned, see 'bub workspace clone'.", r)
+			continue
+		}
+		if f.Manifest != (ManifestFilter{}) {
+			manifests, err := FindManifests(repoDir, r)
+			if err != nil {
+				return nil, err
+			}
+			if len(manifests.Filter(f.Manifest)) == 0 {
+				continue
+			}
+		}
+		repoDirs = append(repoDirs, repoDir)
+	}
+	sort.Strings(repoDirs)
+	return repoDirs, nil
+}
+
+func (w *Workspace) groupNames() (names []string) {
+	for g := range w.Groups {
+		names = append(names, g)
+	}
+	sort.Strings(names)
+	return names
+}
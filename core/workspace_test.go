@@ -0,0 +1,65 @@
+package core
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkspaceSelectRepositories(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bub")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	for _, r := range []string{"billing", "ledger", "web", "archived"} {
+		assert.NoError(t, os.Mkdir(path.Join(dir, r), 0700))
+		assert.NoError(t, MustInitGit(path.Join(dir, r)).RunGit("init", "-q"))
+	}
+	assert.NoError(t, ioutil.WriteFile(path.Join(dir, "billing", ".bench.yml"), []byte("languages: [go]\ntypes: [service]\n"), 0600))
+
+	w, err := LoadWorkspace(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"archived", "billing", "ledger", "web"}, w.Repositories)
+
+	assert.NoError(t, ioutil.WriteFile(path.Join(dir, WorkspaceFile), []byte(`---
+repositories: [billing, ledger, web, missing]
+groups:
+  payments: [billing, ledger, missing]
+  frontend: [web]
+`), 0600))
+	w, err = LoadWorkspace(dir)
+	assert.NoError(t, err)
+	repos := func(names ...string) (dirs []string) {
+		for _, n := range names {
+			dirs = append(dirs, path.Join(dir, n))
+		}
+		return dirs
+	}
+
+	selected, err := w.SelectRepositories(WorkspaceFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, repos("billing", "ledger", "web"), selected)
+
+	selected, err = w.SelectRepositories(WorkspaceFilter{Groups: []string{"payments"}, Exclude: []string{"ledger"}})
+	assert.NoError(t, err)
+	assert.Equal(t, repos("billing"), selected)
+
+	selected, err = w.SelectRepositories(WorkspaceFilter{Groups: []string{"payments", "frontend"}, Only: []string{"web", "ledger"}})
+	assert.NoError(t, err)
+	assert.Equal(t, repos("ledger", "web"), selected)
+
+	selected, err = w.SelectRepositories(WorkspaceFilter{Manifest: ManifestFilter{Type: "service", Language: "go"}})
+	assert.NoError(t, err)
+	assert.Equal(t, repos("billing"), selected)
+
+	_, err = w.SelectRepositories(WorkspaceFilter{Groups: []string{"mobile"}})
+	assert.EqualError(t, err, "unknown group 'mobile', must be one of: frontend, payments")
+	_, err = w.SelectRepositories(WorkspaceFilter{Only: []string{"archived"}})
+	assert.EqualError(t, err, "'archived' is not a repository of the workspace")
+
+	assert.NoError(t, ioutil.WriteFile(path.Join(dir, WorkspaceFile), []byte("repositories: [billing]\ngroups:\n  payments: [ledger]\n"), 0600))
+	_, err = LoadWorkspace(dir)
+	assert.EqualError(t, err, path.Join(dir, WorkspaceFile)+": the group 'payments' contains 'ledger' which is not in the repositories")
+}
@@ -37,7 +37,7 @@ func MustInitConfluence(cfg *core.Configuration) *Confluence {
 }
 
 func mustLoadConfluenceCredentials(cfg *core.Configuration) {
-	err := core.LoadCredentials("Confluence", &cfg.Confluence.Username, &cfg.Confluence.Password, cfg.ResetCredentials)
+	err := cfg.LoadCredentials("Confluence", &cfg.Confluence.Username, &cfg.Confluence.Password)
 	if err != nil {
 		log.Fatalf("Failed to set JIRA credentials: %v", err)
 	}
@@ -115,18 +115,57 @@ type SearchResults struct {
 	} `json:"_links"`
 }
 
+// marshallManifest renders the manifest of the page, with the fields set when the manifest is loaded
+// and in the order of the published pages.
 func (c *Confluence) marshallManifest(m core.Manifest) (string, error) {
-	m.Readme = "See below."
-	m.ChangeLog = "See below."
-	m.Branch = ""
-	m.LastUpdate = 0
-	m.Version = ""
-	i, err := yaml.Marshal(m)
+	i, err := yaml.Marshal(struct {
+		Name          string
+		Active        bool
+		Repository    string
+		LastUpdate    int64
+		Platform      string
+		Platforms     []string
+		Language      string
+		Languages     []string
+		Types         []string
+		Dependencies  []core.Dependency
+		Protocols     []core.Protocol
+		Version       string
+		Branch        string
+		Deploy        core.Deploy
+		Documentation core.Documentation
+		Readme        string
+		ChangeLog     string
+		Page          string
+		Owners        core.Ownership
+	}{
+		Name:          m.Name,
+		Active:        m.Active,
+		Repository:    m.Repository,
+		Platform:      m.Platform,
+		Platforms:     m.Platforms,
+		Language:      m.Language,
+		Languages:     m.Languages,
+		Types:         m.Types,
+		Dependencies:  m.Dependencies,
+		Protocols:     m.Protocols,
+		Deploy:        m.Deploy,
+		Documentation: m.Documentation,
+		Readme:        "See below.",
+		ChangeLog:     "See below.",
+		Page:          m.Page,
+		Owners:        m.Owners,
+	})
 	return string(i), err
 }
 
-func (c *Confluence) findMarkdownFiles(ignoreDirs []string, ignoreCommonFiles bool) (fileList []string, err error) {
-	err = filepath.Walk(".", func(filePath string, f os.FileInfo, err error) error {
+// findMarkdownFiles returns the markdown files of the directory, the paths are relative to the directory.
+func (c *Confluence) findMarkdownFiles(dir string, ignoreDirs []string, ignoreCommonFiles bool) (fileList []string, err error) {
+	err = filepath.Walk(dir, func(filePath string, f os.FileInfo, err error) error {
+		filePath, err = filepath.Rel(dir, filePath)
+		if err != nil {
+			return err
+		}
 		ignoredRootDir := append(ignoreDirs,
 			".github",
 			"bower_components/",
@@ -171,13 +210,19 @@ func (c *Confluence) findMarkdownFiles(ignoreDirs []string, ignoreCommonFiles bo
 	return fileList, err
 }
 
+// joinMarkdownFiles joins the markdown files of the directory of the manifest, i.e. its subdirectory in a monorepo.
 func (c *Confluence) joinMarkdownFiles(m *core.Manifest) (content []byte, err error) {
-	files, err := c.findMarkdownFiles(m.Documentation.IgnoredDirs, true)
+	root, err := core.InitGit().GetRepositoryRootPath()
+	if err != nil {
+		return nil, err
+	}
+	dir := path.Join(root, m.Dir)
+	files, err := c.findMarkdownFiles(dir, m.Documentation.IgnoredDirs, true)
 	if err != nil {
 		return nil, err
 	}
 	for _, filePath := range files {
-		fileContent, err := ioutil.ReadFile(filePath)
+		fileContent, err := ioutil.ReadFile(path.Join(dir, filePath))
 		if err != nil {
 			return nil, err
 		}
@@ -189,7 +234,7 @@ func (c *Confluence) joinMarkdownFiles(m *core.Manifest) (content []byte, err er
 }
 
 func (c *Confluence) generateGitHubLink(filePath string, m *core.Manifest) string {
-	return "[" + filePath + "](https://github.com/" + path.Join(c.cfg.GitHub.Organization, m.Repository, "blob/master", filePath) + ")"
+	return "[" + filePath + "](" + c.cfg.GitHubURL(c.cfg.GitHub.Organization, m.Repository, "blob", m.BaseBranch, m.Dir, filePath) + ")"
 }
 
 func (c *Confluence) createPage(m *core.Manifest) ([]byte, error) {
@@ -201,15 +246,15 @@ func (c *Confluence) createPage(m *core.Manifest) ([]byte, error) {
 	t, err := template.New("readme").Parse(`
 <ac:structured-macro ac:name="info" ac:schema-version="1" ac:macro-id="9289e233-4abf-4957-8884-bef7be9ead8e"><ac:rich-text-body>
 <p>This page is automatically generated. Any changes will be lost.
-	Edit the actual <a href="https://github.com/{{ .Config.GitHub.Organization }}/{{ .Manifest.Repository }}">README</a> instead.</p>
+	Edit the actual <a href="{{ .RepositoryURL }}">README</a> instead.</p>
 </ac:rich-text-body></ac:structured-macro>
 
 <p>
-	<a href="https://github.com/{{ .Config.GitHub.Organization }}/{{ .Manifest.Repository }}">Repository</a> |
+	<a href="{{ .RepositoryURL }}">Repository</a> |
 	<strong>Diffs</strong>
-		<a href="https://github.com/{{ .Config.GitHub.Organization }}/{{ .Manifest.Repository }}/compare/production...master" title="Pending changes from master to Production">Production / Master</a> /
-		<a href="https://github.com/{{ .Config.GitHub.Organization }}/{{ .Manifest.Repository }}/compare/production...staging" title="Pending changes from Staging to Production">Staging / Production</a> /
-		<a href="https://github.com/{{ .Config.GitHub.Organization }}/{{ .Manifest.Repository }}/compare/production-rollback...production" title="Changes in the previous deployment.">Previous / Current Production</a> |
+		<a href="{{ .RepositoryURL }}/compare/production...{{ .Manifest.BaseBranch }}" title="Pending changes from {{ .Manifest.BaseBranch }} to Production">Production / {{ .Manifest.BaseBranch }}</a> /
+		<a href="{{ .RepositoryURL }}/compare/production...staging" title="Pending changes from Staging to Production">Staging / Production</a> /
+		<a href="{{ .RepositoryURL }}/compare/production-rollback...production" title="Changes in the previous deployment.">Previous / Current Production</a> |
 	<a href="{{ .Config.Jenkins.Server }}/job/{{ .Config.GitHub.Organization }}/job/{{ .Manifest.Repository }}">Jenkins</a> |
 	<a href="{{ .Config.Splunk.Server }}/en-US/app/search/search/?dispatch.sample_ratio=1&amp;earliest=rt-1h&amp;latest=rtnow&amp;q=search%20sourcetype%3D{{ .Manifest.Deploy.Environment }}-{{ .Manifest.Name }}*&amp;display.page.search.mode=smart">Splunk</a>
 </p>
@@ -233,10 +278,12 @@ func (c *Confluence) createPage(m *core.Manifest) ([]byte, error) {
 		Config            core.Configuration
 		Manifest          core.Manifest
 		MarshaledManifest string
+		RepositoryURL     string
 	}{
 		Manifest:          *m,
 		Config:            *c.cfg,
 		MarshaledManifest: marshaledManifest,
+		RepositoryURL:     c.cfg.GitHubURL(c.cfg.GitHub.Organization, m.Repository),
 	})
 	writer.Flush()
 
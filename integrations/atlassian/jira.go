@@ -34,7 +34,7 @@ func MustInitJIRA(cfg *core.Configuration) *JIRA {
 }
 
 func mustLoadJIRACredentials(cfg *core.Configuration) {
-	err := core.LoadCredentials("JIRA", &cfg.JIRA.Username, &cfg.JIRA.Password, cfg.ResetCredentials)
+	err := cfg.LoadCredentials("JIRA", &cfg.JIRA.Username, &cfg.JIRA.Password)
 	if err != nil {
 		log.Fatalf("Failed to set JIRA credentials: %v", err)
 	}
@@ -50,8 +50,9 @@ func MustSetupJIRA(cfg *core.Configuration) {
 	mustLoadJIRACredentials(cfg)
 }
 
+// IsEnabled returns true if the issues are transitioned by the workflows, jira.enabled must be set.
 func (j *JIRA) IsEnabled() bool {
-	return j.cfg.JIRA.Enabled
+	return j.cfg.JIRA.Enabled != nil && *j.cfg.JIRA.Enabled
 }
 
 func (j *JIRA) init(cfg *core.Configuration) error {
@@ -245,7 +246,7 @@ func (j *JIRA) CreateBranchFromAssignedIssue() error {
 }
 
 func (j *JIRA) CreateBranchFromIssue(issue *jira.Issue, repoDir string, forceNewBranch bool) error {
-	git := core.MustInitGit(repoDir)
+	git := core.MustInitGit(repoDir).WithConfig(j.cfg)
 	git.Fetch()
 	err := git.CreateBranch(issue.Key + " " + issue.Fields.Summary)
 	if err != nil {
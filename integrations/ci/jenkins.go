@@ -35,7 +35,7 @@ func MustInitJenkins(cfg *core.Configuration, m *core.Manifest) *Jenkins {
 }
 
 func mustLoadJenkinsCredentials(cfg *core.Configuration) {
-	err := core.LoadCredentials("Jenkins", &cfg.Jenkins.Username, &cfg.Jenkins.Password, cfg.ResetCredentials)
+	err := cfg.LoadCredentials("Jenkins", &cfg.Jenkins.Username, &cfg.Jenkins.Password)
 	if err != nil {
 		log.Fatalf("Failed to set JIRA credentials: %v", err)
 	}
@@ -0,0 +1,315 @@
+package doctor
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/j-martin/bub/core"
+	"github.com/j-martin/bub/utils"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	Pass = "PASS"
+	Fail = "FAIL"
+	Skip = "SKIP"
+)
+
+const updateCredentialsHint = "Run 'BUB_UPDATE_CREDENTIALS=1 bub setup' or 'bub credentials test' to check the stored credentials."
+
+type Result struct {
+	Check, Status, Detail, Hint string
+}
+
+// Doctor checks that bub and every configured integration are working.
+type Doctor struct {
+	cfg    *core.Configuration
+	client *http.Client
+	// GitHubAPI is the base URL of the GitHub API.
+	GitHubAPI string
+	// TokenDir contains the Vault tokens, e.g. ~/.config/bub/token.vault.example.com
+	TokenDir string
+}
+
+func New(cfg *core.Configuration) *Doctor {
+	return &Doctor{
+		cfg:       cfg,
+		client:    &http.Client{Timeout: 10 * time.Second},
+		GitHubAPI: cfg.GitHubAPIURL(),
+		TokenDir:  core.GetConfigPath(""),
+	}
+}
+
+func (d *Doctor) Run() []Result {
+	results := []Result{
+		d.checkGit(),
+		d.checkRepository(),
+		d.checkKeyring(),
+		d.checkIntegration("github", d.checkGitHub),
+		d.checkIntegration("jira", d.checkJIRA),
+		d.checkIntegration("confluence", d.checkConfluence),
+		d.checkIntegration("jenkins", d.checkJenkins),
+	}
+	if !d.cfg.IsIntegrationEnabled("vault") {
+		return append(results, disabledResult("vault"))
+	}
+	return append(results, d.checkVaultTokens()...)
+}
+
+// checkIntegration runs the check only if the integration is enabled, see 'bub setup'.
+func (d *Doctor) checkIntegration(integration string, check func() Result) Result {
+	if !d.cfg.IsIntegrationEnabled(integration) {
+		return disabledResult(integration)
+	}
+	return check()
+}
+
+func disabledResult(integration string) Result {
+	return Result{Check: integration, Status: Skip, Detail: integration + ".enabled is false"}
+}
+
+func HasFailures(results []Result) bool {
+	for _, r := range results {
+		if r.Status == Fail {
+			return true
+		}
+	}
+	return false
+}
+
+func PrintResults(w io.Writer, results []Result) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "Check\tStatus\tDetail")
+	for _, r := range results {
+		fmt.Fprintln(table, strings.Join([]string{r.Check, r.Status, r.Detail}, "\t"))
+	}
+	if err := table.Flush(); err != nil {
+		return err
+	}
+	for _, r := range results {
+		if r.Status == Fail && r.Hint != "" {
+			fmt.Fprintf(w, "\n%v: %v", r.Check, r.Hint)
+		}
+	}
+	fmt.Fprintln(w)
+	return nil
+}
+
+func (d *Doctor) checkGit() Result {
+	r := Result{Check: "git"}
+	if _, err := exec.LookPath("git"); err != nil {
+		r.Status, r.Detail, r.Hint = Fail, err.Error(), "Install git and make sure it is in your PATH."
+		return r
+	}
+	version, err := utils.RunCmdWithStdout("git", "--version")
+	if err != nil {
+		r.Status, r.Detail = Fail, err.Error()
+		return r
+	}
+	r.Status, r.Detail = Pass, version
+	return r
+}
+
+func (d *Doctor) checkRepository() Result {
+	r := Result{Check: "repository"}
+	if !utils.InRepository() {
+		r.Status, r.Detail = Skip, "not in a repository"
+		return r
+	}
+	root, err := core.InitGit().GetRepositoryRootPath()
+	if err != nil {
+		r.Status, r.Detail = Fail, err.Error()
+		return r
+	}
+	r.Status, r.Detail = Pass, root
+	return r
+}
+
+func (d *Doctor) checkKeyring() Result {
+	r := Result{Check: "keyring"}
+	if err := core.CheckKeyring(); err != nil {
+		r.Status, r.Detail = Fail, err.Error()
+		r.Hint = "The OS keyring is not reachable, use another backend in the 'credentials' section of 'bub config'."
+		return r
+	}
+	r.Status, r.Detail = Pass, "reachable"
+	return r
+}
+
+// lookupCredentials loads the credentials without prompting.
+func (d *Doctor) lookupCredentials(items map[string]*string) error {
+	for item, ptr := range items {
+		if err := d.cfg.LookupCredentialItem(item, ptr); err != nil {
+			return fmt.Errorf("'%v': %v", item, err)
+		}
+	}
+	return nil
+}
+
+// get queries the endpoint and decodes the JSON response, if result is set.
+func (d *Doctor) get(uri string, setAuth func(*http.Request), result interface{}) error {
+	req, err := http.NewRequest("GET", uri, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	setAuth(req)
+	res, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%v returned %v", uri, res.Status)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(result)
+}
+
+func basicAuth(username, password string) func(*http.Request) {
+	return func(req *http.Request) {
+		req.SetBasicAuth(username, password)
+	}
+}
+
+func (d *Doctor) checkGitHub() Result {
+	r := Result{Check: "github"}
+	if err := d.lookupCredentials(map[string]*string{"GitHub Token": &d.cfg.GitHub.Token}); err != nil {
+		r.Status, r.Detail, r.Hint = Fail, err.Error(), "Run 'bub setup' to create and store a GitHub token."
+		return r
+	}
+	var user struct {
+		Login string `json:"login"`
+	}
+	setToken := func(req *http.Request) {
+		req.Header.Set("Authorization", "token "+d.cfg.GitHub.Token)
+	}
+	if err := d.get(strings.TrimSuffix(d.GitHubAPI, "/")+"/user", setToken, &user); err != nil {
+		r.Status, r.Detail, r.Hint = Fail, err.Error(), "The token may be expired or revoked. "+updateCredentialsHint
+		return r
+	}
+	r.Status, r.Detail = Pass, "authenticated as "+user.Login
+	return r
+}
+
+func (d *Doctor) checkJIRA() Result {
+	r := Result{Check: "jira"}
+	if d.cfg.JIRA.Server == "" {
+		r.Status, r.Detail = Skip, "jira.server is not configured"
+		return r
+	}
+	err := d.lookupCredentials(map[string]*string{
+		"JIRA Username": &d.cfg.JIRA.Username,
+		"JIRA Password": &d.cfg.JIRA.Password,
+	})
+	if err != nil {
+		r.Status, r.Detail, r.Hint = Fail, err.Error(), "Run 'bub setup' to store your JIRA credentials."
+		return r
+	}
+	var user struct {
+		Name        string `json:"name"`
+		DisplayName string `json:"displayName"`
+	}
+	uri := strings.TrimSuffix(d.cfg.JIRA.Server, "/") + "/rest/api/2/myself"
+	if err = d.get(uri, basicAuth(d.cfg.JIRA.Username, d.cfg.JIRA.Password), &user); err != nil {
+		r.Status, r.Detail, r.Hint = Fail, err.Error(), "Check jira.server with 'bub config validate'. "+updateCredentialsHint
+		return r
+	}
+	if user.DisplayName == "" {
+		user.DisplayName = user.Name
+	}
+	r.Status, r.Detail = Pass, "authenticated as "+user.DisplayName
+	return r
+}
+
+func (d *Doctor) checkConfluence() Result {
+	r := Result{Check: "confluence"}
+	if d.cfg.Confluence.Server == "" {
+		r.Status, r.Detail = Skip, "confluence.server is not configured"
+		return r
+	}
+	err := d.lookupCredentials(map[string]*string{
+		"Confluence Username": &d.cfg.Confluence.Username,
+		"Confluence Password": &d.cfg.Confluence.Password,
+	})
+	if err != nil {
+		r.Status, r.Detail, r.Hint = Fail, err.Error(), "Run 'bub setup' to store your Confluence credentials."
+		return r
+	}
+	uri := strings.TrimSuffix(d.cfg.Confluence.Server, "/") + "/rest/api/content?limit=1"
+	if err = d.get(uri, basicAuth(d.cfg.Confluence.Username, d.cfg.Confluence.Password), nil); err != nil {
+		r.Status, r.Detail, r.Hint = Fail, err.Error(), "Check confluence.server with 'bub config validate'. "+updateCredentialsHint
+		return r
+	}
+	r.Status, r.Detail = Pass, "authenticated as "+d.cfg.Confluence.Username
+	return r
+}
+
+func (d *Doctor) checkJenkins() Result {
+	r := Result{Check: "jenkins"}
+	if d.cfg.Jenkins.Server == "" {
+		r.Status, r.Detail = Skip, "jenkins.server is not configured"
+		return r
+	}
+	err := d.lookupCredentials(map[string]*string{
+		"Jenkins Username": &d.cfg.Jenkins.Username,
+		"Jenkins Password": &d.cfg.Jenkins.Password,
+	})
+	if err != nil {
+		r.Status, r.Detail, r.Hint = Fail, err.Error(), "Run 'bub setup' to store your Jenkins username and API token."
+		return r
+	}
+	uri := strings.TrimSuffix(d.cfg.Jenkins.Server, "/") + "/api/json"
+	if err = d.get(uri, basicAuth(d.cfg.Jenkins.Username, d.cfg.Jenkins.Password), nil); err != nil {
+		r.Status, r.Detail, r.Hint = Fail, err.Error(), "The password must be your Jenkins API token. "+updateCredentialsHint
+		return r
+	}
+	r.Status, r.Detail = Pass, "authenticated as "+d.cfg.Jenkins.Username
+	return r
+}
+
+func (d *Doctor) checkVaultTokens() (results []Result) {
+	tokenFiles, err := filepath.Glob(path.Join(d.TokenDir, "token.*"))
+	if err != nil {
+		return []Result{{Check: "vault", Status: Fail, Detail: err.Error()}}
+	}
+	if len(tokenFiles) == 0 {
+		return []Result{{Check: "vault", Status: Skip, Detail: "no token found"}}
+	}
+	for _, tokenFile := range tokenFiles {
+		host := strings.TrimPrefix(path.Base(tokenFile), "token.")
+		r := Result{Check: "vault " + host}
+		token, err := readToken(tokenFile)
+		if err != nil {
+			r.Status, r.Detail = Fail, err.Error()
+			results = append(results, r)
+			continue
+		}
+		if token == "" {
+			r.Status, r.Detail = Fail, "empty token"
+			results = append(results, r)
+			continue
+		}
+		// Vault is only reachable through the SSH tunnel opened by the Vault commands, on a random local port.
+		r.Status, r.Detail = Skip, "token found, validating it requires the SSH tunnel to "+host
+		results = append(results, r)
+	}
+	return results
+}
+
+func readToken(tokenFile string) (string, error) {
+	content, err := ioutil.ReadFile(tokenFile)
+	if err != nil {
+		return "", err
+	}
+	return strings.Trim(string(content), "\n"), nil
+}
@@ -0,0 +1,150 @@
+package doctor
+
+import (
+	"github.com/j-martin/bub/core"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+)
+
+func newStubServer(endpoint string, check func(*http.Request) bool, body string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != endpoint {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if !check(r) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+}
+
+func hasBasicAuth(username, password string) func(*http.Request) bool {
+	return func(r *http.Request) bool {
+		u, p, ok := r.BasicAuth()
+		return ok && u == username && p == password
+	}
+}
+
+func TestCheckJIRA(t *testing.T) {
+	t.Parallel()
+	server := newStubServer("/rest/api/2/myself", hasBasicAuth("jdoe", "secret"), `{"name": "jdoe", "displayName": "John Doe"}`)
+	defer server.Close()
+
+	cfg := &core.Configuration{}
+	cfg.JIRA.Server = server.URL
+	cfg.JIRA.Username = "jdoe"
+	cfg.JIRA.Password = "secret"
+	r := New(cfg).checkJIRA()
+	assert.Equal(t, Pass, r.Status)
+	assert.Equal(t, "authenticated as John Doe", r.Detail)
+
+	cfg.JIRA.Password = "wrong"
+	r = New(cfg).checkJIRA()
+	assert.Equal(t, Fail, r.Status)
+	assert.NotEmpty(t, r.Hint)
+}
+
+func TestCheckGitHub(t *testing.T) {
+	t.Parallel()
+	server := newStubServer("/user", func(r *http.Request) bool {
+		return r.Header.Get("Authorization") == "token abc"
+	}, `{"login": "octocat"}`)
+	defer server.Close()
+
+	cfg := &core.Configuration{}
+	cfg.GitHub.Token = "abc"
+	d := New(cfg)
+	d.GitHubAPI = server.URL
+	r := d.checkGitHub()
+	assert.Equal(t, Pass, r.Status)
+	assert.Equal(t, "authenticated as octocat", r.Detail)
+}
+
+func TestCheckJenkinsAndConfluence(t *testing.T) {
+	t.Parallel()
+	jenkins := newStubServer("/api/json", hasBasicAuth("jdoe", "api-token"), `{}`)
+	defer jenkins.Close()
+	confluence := newStubServer("/wiki/rest/api/content", hasBasicAuth("jdoe", "wrong"), `{}`)
+	defer confluence.Close()
+
+	cfg := &core.Configuration{}
+	cfg.Jenkins.Server = jenkins.URL
+	cfg.Jenkins.Username = "jdoe"
+	cfg.Jenkins.Password = "api-token"
+	cfg.Confluence.Server = confluence.URL + "/wiki"
+	cfg.Confluence.Username = "jdoe"
+	cfg.Confluence.Password = "secret"
+	d := New(cfg)
+	assert.Equal(t, Pass, d.checkJenkins().Status)
+	assert.Equal(t, Fail, d.checkConfluence().Status)
+}
+
+func TestCheckVaultTokens(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "bub-doctor")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(path.Join(dir, "token.vault.example.com"), []byte("valid-token\n"), 0600)
+	ioutil.WriteFile(path.Join(dir, "token.vault.example.org"), []byte("\n"), 0600)
+
+	d := New(&core.Configuration{})
+	d.TokenDir = dir
+	results := d.checkVaultTokens()
+	assert.Equal(t, []string{Skip, Fail}, []string{results[0].Status, results[1].Status})
+	assert.Equal(t, "token found, validating it requires the SSH tunnel to vault.example.com", results[0].Detail)
+	assert.Equal(t, "empty token", results[1].Detail)
+}
+
+func TestCheckSkipsUnconfiguredIntegrations(t *testing.T) {
+	t.Parallel()
+	d := New(&core.Configuration{})
+	assert.Equal(t, Skip, d.checkJIRA().Status)
+	assert.Equal(t, Skip, d.checkConfluence().Status)
+	assert.Equal(t, Skip, d.checkJenkins().Status)
+}
+
+func TestRunSkipsDisabledIntegrations(t *testing.T) {
+	t.Parallel()
+	enabled, disabled := true, false
+	cfg := &core.Configuration{}
+	cfg.JIRA.Enabled = &enabled
+	cfg.GitHub.Enabled, cfg.Confluence.Enabled, cfg.Jenkins.Enabled, cfg.Vault.Enabled = &disabled, &disabled, &disabled, &disabled
+	details := map[string]string{}
+	for _, r := range New(cfg).Run() {
+		details[r.Check] = r.Detail
+	}
+	assert.Equal(t, "jira.server is not configured", details["jira"])
+	for _, integration := range []string{"github", "confluence", "jenkins", "vault"} {
+		assert.Equal(t, integration+".enabled is false", details[integration])
+	}
+}
+
+func TestCheckIntegrationWithoutEnabledFlag(t *testing.T) {
+	t.Parallel()
+	// a config created before 'bub setup' recorded the enabled flags.
+	cfg := &core.Configuration{}
+	assert.NoError(t, yaml.Unmarshal([]byte(`---
+github:
+  organization: benchlabs
+jira:
+  server: "https://example.atlassian.net"
+  project: PL
+vault:
+  server: "https://vault.example.com"
+`), cfg))
+	d := New(cfg)
+	for _, integration := range core.Integrations {
+		r := d.checkIntegration(integration, func() Result {
+			return Result{Check: integration, Status: Pass}
+		})
+		assert.Equal(t, Pass, r.Status, integration)
+	}
+}
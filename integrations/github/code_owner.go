@@ -1,27 +1,51 @@
 package github
 
 import (
+	"encoding/json"
+	"fmt"
 	"github.com/j-martin/bub/core"
 	"github.com/j-martin/bub/utils"
+	"io"
 	"io/ioutil"
 	"path"
 	"regexp"
+	"sort"
 	"strings"
+	"text/tabwriter"
 )
 
 type OwnerMap map[string][]string
 
 func (gh *GitHub) PopulateOwners(m *core.Manifest) error {
-	owners, err := gh.ListCodeOwners()
+	return PopulateOwners(gh.cfg, m)
+}
+
+// PopulateOwners sets the owners of the manifest from the CODEOWNERS file, the GitHub API is not used.
+func PopulateOwners(cfg *core.Configuration, m *core.Manifest) error {
+	owners, err := listCodeOwners(cfg)
 	if err != nil {
 		return err
 	}
 	m.Owners = owners
+	if m.Dir == "" {
+		return nil
+	}
+	// in a monorepo, only the rules applying to the directory of the manifest are kept.
+	m.Owners = make(core.Ownership)
+	for rule, o := range owners {
+		if matchesCodeOwnerRules(rule, m.Dir+"/") || strings.HasPrefix(strings.TrimPrefix(rule, "/"), m.Dir+"/") {
+			m.Owners[rule] = o
+		}
+	}
 	return nil
 }
 
 func (gh *GitHub) ListCodeOwners() (core.Ownership, error) {
-	owners, err := gh.GetCodeOwners()
+	return listCodeOwners(gh.cfg)
+}
+
+func listCodeOwners(cfg *core.Configuration) (core.Ownership, error) {
+	owners, err := readRepositoryCodeOwners()
 	if err != nil {
 		return nil, err
 	}
@@ -36,7 +60,7 @@ func (gh *GitHub) ListCodeOwners() (core.Ownership, error) {
 			} else {
 				u.Email = user
 			}
-			gh.cfg.PopulateUser(&u)
+			cfg.PopulateUser(&u)
 			ownerList = append(ownerList, u)
 		}
 		ownerMap[fPath] = ownerList
@@ -48,81 +72,255 @@ type Reviewers []string
 
 func (gh *GitHub) ListReviewers() (reviewers Reviewers, err error) {
 	reviewers = gh.cfg.GitHub.Reviewers
-	owners, err := gh.ListCodeOwners()
+	rules, err := readRepositoryCodeOwnerRules()
 	if err != nil {
 		return nil, err
 	}
 
-	for _, filename := range core.MustInitGit("").ListFileChanged() {
-		for rule, o := range owners {
-			if matchesCodeOwnerRules(rule, filename) {
-				for _, owner := range o {
-					if owner.GitHub == gh.cfg.GitHub.Username {
-						continue
-					}
-					reviewers = append(reviewers, owner.GitHub)
-				}
+	for _, filename := range core.MustInitGit("").WithConfig(gh.cfg).ListFileChanged() {
+		for _, owner := range resolveCodeOwners(rules, filename) {
+			u := core.User{GitHub: strings.TrimLeft(owner, "@")}
+			if !strings.HasPrefix(owner, "@") {
+				u = core.User{Email: owner}
+				gh.cfg.PopulateUser(&u)
+			}
+			if u.GitHub == "" || u.GitHub == gh.cfg.GitHub.Username {
+				continue
 			}
+			reviewers = append(reviewers, u.GitHub)
 		}
 	}
 	return utils.RemoveDuplicatesUnordered(reviewers), nil
 }
 
+// CodeOwnerRule is a line of the CODEOWNERS file, the last rule matching a file takes precedence.
+type CodeOwnerRule struct {
+	Pattern string
+	Owners  []string
+	Line    int
+}
+
+// resolveCodeOwners returns the owners of the file, none if the last matching rule has no owner.
+func resolveCodeOwners(rules []CodeOwnerRule, filename string) (owners []string) {
+	for _, r := range rules {
+		if matchesCodeOwnerRules(r.Pattern, filename) {
+			owners = r.Owners
+		}
+	}
+	return owners
+}
+
+// matchesCodeOwnerRules follows the gitignore rules used by GitHub, e.g. 'docs/*' only matches the files
+// directly in docs, '/build/' everything under the build directory at the root and 'apps/' any apps directory.
 func matchesCodeOwnerRules(rule, filename string) bool {
-	if rule == "*" {
-		return true
+	return codeOwnerPattern(rule).MatchString(strings.TrimPrefix(filename, "/"))
+}
+
+func codeOwnerPattern(rule string) *regexp.Regexp {
+	trimmed := strings.Trim(rule, "/")
+	if trimmed == "" || trimmed == "*" || trimmed == "**" {
+		return regexp.MustCompile(".*")
 	}
-	if strings.HasPrefix(rule, "*") {
-		return strings.HasSuffix(filename, rule[1:])
+	prefix := "^(.*/)?"
+	if strings.HasPrefix(rule, "/") || strings.Contains(trimmed, "/") {
+		prefix = "^"
 	}
-	if strings.HasSuffix(rule, "*") && strings.HasPrefix(filename, rule[0:len(rule)-1]) {
-		// If 'something/*', only the file on that level are included.
-		return !strings.Contains(strings.TrimPrefix(filename, rule), "/")
+	var pattern string
+	for i := 0; i < len(trimmed); i++ {
+		switch {
+		case strings.HasPrefix(trimmed[i:], "**/"):
+			pattern += "(.*/)?"
+			i += 2
+		case strings.HasPrefix(trimmed[i:], "**"):
+			pattern += ".*"
+			i++
+		case trimmed[i] == '*':
+			pattern += "[^/]*"
+		case trimmed[i] == '?':
+			pattern += "[^/]"
+		default:
+			pattern += regexp.QuoteMeta(trimmed[i : i+1])
+		}
 	}
-	return strings.HasPrefix(filename, rule)
+	suffix := "(/.*)?$"
+	if strings.HasSuffix(rule, "/") {
+		// only the directories.
+		suffix = "/.*$"
+	} else if strings.Contains(path.Base(trimmed), "*") {
+		// e.g. 'docs/*' does not match the nested files.
+		suffix = "$"
+	}
+	return regexp.MustCompile(prefix + pattern + suffix)
 }
 
-func (gh *GitHub) GetCodeOwners() (owners OwnerMap, err error) {
-	repo, err := core.MustInitGit("").GetRepositoryRootPath()
+// OwnershipReport is the coverage of the tracked files by the CODEOWNERS rules.
+type OwnershipReport struct {
+	Files int `json:"files"`
+	// Owners is the number of files of each owner.
+	Owners  map[string]int `json:"owners"`
+	Unowned []string       `json:"unowned"`
+	// UnknownOwners are missing from the users of the config, the teams are not checked.
+	UnknownOwners []string `json:"unknownOwners"`
+}
+
+// ReadOwnershipReport resolves the owners of every file tracked in the repository.
+func ReadOwnershipReport(cfg *core.Configuration) (*OwnershipReport, error) {
+	root, err := core.MustInitGit("").GetRepositoryRootPath()
 	if err != nil {
-		return owners, err
+		return nil, err
 	}
-	for _, i := range []string{"", ".github", "docs"} {
-		owners, err = readCodeOwner(repo, i)
-		if err != nil && err != utils.FileDoesNotExist {
-			return nil, err
+	rules, err := readRepositoryCodeOwnerRules()
+	if err != nil {
+		return nil, err
+	}
+	files, err := core.MustInitGit(root).ListTrackedFiles()
+	if err != nil {
+		return nil, err
+	}
+	return buildOwnershipReport(cfg, rules, files), nil
+}
+
+func buildOwnershipReport(cfg *core.Configuration, rules []CodeOwnerRule, files []string) *OwnershipReport {
+	report := &OwnershipReport{Files: len(files), Owners: map[string]int{}, Unowned: []string{}, UnknownOwners: []string{}}
+	for _, f := range files {
+		owners := resolveCodeOwners(rules, f)
+		if len(owners) == 0 {
+			report.Unowned = append(report.Unowned, f)
+		}
+		for _, o := range owners {
+			report.Owners[o]++
+		}
+	}
+	for o := range report.Owners {
+		if !isConfiguredOwner(cfg, o) {
+			report.UnknownOwners = append(report.UnknownOwners, o)
+		}
+	}
+	sort.Strings(report.UnknownOwners)
+	return report
+}
+
+// isConfiguredOwner returns true if the owner (@username or email) is in the users of the config.
+func isConfiguredOwner(cfg *core.Configuration, owner string) bool {
+	if strings.Contains(owner, "/") {
+		// e.g. @org/team
+		return true
+	}
+	for _, u := range cfg.Users {
+		if strings.EqualFold(owner, "@"+u.GitHub) || strings.EqualFold(owner, u.Email) {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteOwnershipReport writes the report as a table or JSON.
+func WriteOwnershipReport(w io.Writer, report *OwnershipReport, format string) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case "table", "":
+		var owners []string
+		for o := range report.Owners {
+			owners = append(owners, o)
+		}
+		sort.Slice(owners, func(i, j int) bool {
+			if report.Owners[owners[i]] != report.Owners[owners[j]] {
+				return report.Owners[owners[i]] > report.Owners[owners[j]]
+			}
+			return owners[i] < owners[j]
+		})
+		table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(table, "Owner\tFiles")
+		for _, o := range owners {
+			fmt.Fprintf(table, "%v\t%v\n", o, report.Owners[o])
+		}
+		fmt.Fprintf(table, "(unowned)\t%v\n", len(report.Unowned))
+		fmt.Fprintf(table, "(total)\t%v\n", report.Files)
+		if err := table.Flush(); err != nil {
+			return err
+		}
+		if len(report.Unowned) > 0 {
+			fmt.Fprintf(w, "\nUnowned files:\n  %v\n", strings.Join(report.Unowned, "\n  "))
 		}
-		if len(owners) > 0 {
-			return owners, nil
+		if len(report.UnknownOwners) > 0 {
+			fmt.Fprintf(w, "\nOwners missing from the users of the config:\n  %v\n", strings.Join(report.UnknownOwners, "\n  "))
 		}
+		return nil
+	}
+	return fmt.Errorf("unknown format '%v', must be one of: table, json", format)
+}
+
+func (gh *GitHub) GetCodeOwners() (owners OwnerMap, err error) {
+	return readRepositoryCodeOwners()
+}
+
+func readRepositoryCodeOwners() (owners OwnerMap, err error) {
+	rules, err := readRepositoryCodeOwnerRules()
+	if err != nil {
+		return nil, err
+	}
+	owners = make(OwnerMap)
+	for _, r := range rules {
+		owners[r.Pattern] = r.Owners
 	}
 	return owners, nil
 }
 
-func readCodeOwner(repo, dir string) (owners OwnerMap, err error) {
-	filePath := path.Join(repo, dir, "CODEOWNERS")
-	exists, err := utils.PathExists(filePath)
-	if !exists {
-		return owners, utils.FileDoesNotExist
+// readRepositoryCodeOwnerRules reads the rules of the repository, in the order of the file.
+func readRepositoryCodeOwnerRules() ([]CodeOwnerRule, error) {
+	repo, err := core.MustInitGit("").GetRepositoryRootPath()
+	if err != nil {
+		return nil, err
+	}
+	filePath, err := findCodeOwnersFile(repo)
+	if err == utils.FileDoesNotExist {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
 	}
 	data, err := ioutil.ReadFile(filePath)
 	if err != nil {
-		return owners, err
+		return nil, err
+	}
+	return parseCodeOwnerRules(string(data)), nil
+}
+
+// findCodeOwnersFile returns the CODEOWNERS file of the repository, either at the root, in .github or in docs.
+func findCodeOwnersFile(repo string) (string, error) {
+	for _, i := range []string{"", ".github", "docs"} {
+		filePath := path.Join(repo, i, "CODEOWNERS")
+		exists, err := utils.PathExists(filePath)
+		if err != nil {
+			return "", err
+		}
+		if exists {
+			return filePath, nil
+		}
 	}
-	return parseCodeOwnerContent(string(data))
+	return "", utils.FileDoesNotExist
 }
 
 func parseCodeOwnerContent(body string) (owners OwnerMap, err error) {
 	owners = make(OwnerMap)
-	re := regexp.MustCompile(" {2,}")
-	for _, line := range strings.Split(body, "\n") {
-		if strings.HasPrefix(line, "#") || line == "" {
+	for _, r := range parseCodeOwnerRules(body) {
+		owners[r.Pattern] = r.Owners
+	}
+	return owners, err
+}
+
+var codeOwnerComment = regexp.MustCompile(`(^|\s)#.*$`)
+
+func parseCodeOwnerRules(body string) (rules []CodeOwnerRule) {
+	for i, line := range strings.Split(body, "\n") {
+		items := strings.Fields(codeOwnerComment.ReplaceAllString(line, ""))
+		if len(items) == 0 {
 			continue
 		}
-		line := re.ReplaceAllString(strings.Trim(line, " "), " ")
-		items := strings.Split(line, " ")
-		ownershipPath := items[0]
-		owners[ownershipPath] = items[1:]
+		rules = append(rules, CodeOwnerRule{Pattern: items[0], Owners: items[1:], Line: i + 1})
 	}
-	return owners, err
+	return rules
 }
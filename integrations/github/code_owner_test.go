@@ -1,6 +1,7 @@
 package github
 
 import (
+	"github.com/j-martin/bub/core"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
@@ -49,3 +50,77 @@ apps/ @octocat
 	assert.Equal(t, len(result), 6)
 	assert.Equal(t, result["*.go"], []string{"docs@example.com"})
 }
+
+func TestMatchesCodeOwnerRules(t *testing.T) {
+	cases := []struct {
+		rule, filename string
+		expected       bool
+	}{
+		{"*", "any/file.go", true},
+		{"*.js", "app.js", true},
+		{"*.js", "web/src/app.js", true},
+		{"*.js", "app.jsx", false},
+		{"/build/logs/", "build/logs/today/app.log", true},
+		{"/build/logs/", "src/build/logs/app.log", false},
+		{"docs/*", "docs/getting-started.md", true},
+		{"docs/*", "docs/build-app/troubleshooting.md", false},
+		{"apps/", "apps/web/index.js", true},
+		{"apps/", "services/apps/web/index.js", true},
+		{"apps/", "apps", false},
+		{"/docs", "docs/index.md", true},
+		{"/docs", "documentation/index.md", false},
+		{"services/billing", "services/billing/", true},
+		{"**/logs", "deep/down/logs/app.log", true},
+		{"Makefile", "tools/Makefile", true},
+		{"/Makefile", "tools/Makefile", false},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, matchesCodeOwnerRules(c.rule, c.filename), "%v %v", c.rule, c.filename)
+	}
+}
+
+func TestBuildOwnershipReport(t *testing.T) {
+	rules := parseCodeOwnerRules(`
+*          @global-owner
+*.go       @gopher docs@example.com # inline comment
+/vendor/
+docs/*     @org/writers
+`)
+	assert.Equal(t, CodeOwnerRule{Pattern: "*.go", Owners: []string{"@gopher", "docs@example.com"}, Line: 3}, rules[1])
+
+	cfg := &core.Configuration{Users: []core.User{{GitHub: "gopher"}, {Email: "Docs@example.com"}}}
+	report := buildOwnershipReport(cfg, rules, []string{"main.go", "README.md", "vendor/lib/lib.go", "docs/index.md", "docs/api/index.md"})
+	assert.Equal(t, 5, report.Files)
+	assert.Equal(t, map[string]int{"@global-owner": 2, "@gopher": 1, "docs@example.com": 1, "@org/writers": 1}, report.Owners)
+	assert.Equal(t, []string{"vendor/lib/lib.go"}, report.Unowned)
+	assert.Equal(t, []string{"@global-owner"}, report.UnknownOwners)
+}
+
+func TestLintCodeOwnerRules(t *testing.T) {
+	rules := parseCodeOwnerRules(`# owners
+*             @global-owner
+*.go          @gopher
+/docs/        @org/writers
+/missing/     @gopher
+!vendor/      @gopher
+web/[a-z]*    @frontend
+/docs/        @org/editors
+cmd/          gopher
+/cmd/main.go  jane@example.com unknown@example.com
+/cmd/         @gopher
+`)
+	cfg := &core.Configuration{Users: []core.User{{GitHub: "jane", Email: "jane@example.com"}}}
+	files := []string{"README.md", "main.go", "docs/index.md", "cmd/main.go", "cmd/root.go"}
+	assert.Equal(t, []CodeOwnersProblem{
+		{Line: 4, Message: "'/docs/' is repeated on line 8 which takes precedence"},
+		{Line: 5, Message: "'/missing/' does not match any tracked file"},
+		{Line: 6, Message: "negated pattern '!vendor/' is not supported"},
+		{Line: 6, Message: "'!vendor/' does not match any tracked file"},
+		{Line: 7, Message: "character ranges in 'web/[a-z]*' are not supported"},
+		{Line: 7, Message: "'web/[a-z]*' does not match any tracked file"},
+		{Line: 9, Message: "invalid owner 'gopher', must be @username, @org/team or an email"},
+		{Line: 9, Message: "'cmd/' never applies, its files are matched by the later rules on line 11"},
+		{Line: 10, Message: "'unknown@example.com' is not in the users of the config"},
+		{Line: 10, Message: "'/cmd/main.go' never applies, its files are matched by the later rules on line 11"},
+	}, lintCodeOwnerRules(cfg, rules, files))
+}
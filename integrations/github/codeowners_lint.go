@@ -0,0 +1,184 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/github"
+	"github.com/j-martin/bub/core"
+)
+
+var (
+	codeOwnerUsername = regexp.MustCompile(`^@[A-Za-z0-9][A-Za-z0-9-]*$`)
+	codeOwnerTeam     = regexp.MustCompile(`^@[A-Za-z0-9][A-Za-z0-9-]*/[A-Za-z0-9_.-]+$`)
+	codeOwnerEmail    = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+)
+
+// CodeOwnersProblem is an issue of a line of the CODEOWNERS file.
+type CodeOwnersProblem struct {
+	Line    int
+	Message string
+}
+
+// LintCodeOwners checks the CODEOWNERS of the repository against the tracked files and the users of the config.
+// The usernames and teams are checked against the organization unless gh is nil. Returns the path of the file
+// relative to the root of the repository.
+func LintCodeOwners(cfg *core.Configuration, gh *GitHub) (string, []CodeOwnersProblem, error) {
+	root, err := core.MustInitGit("").GetRepositoryRootPath()
+	if err != nil {
+		return "", nil, err
+	}
+	filePath, err := findCodeOwnersFile(root)
+	if err != nil {
+		return "", nil, errors.New("no CODEOWNERS file found at the root, in .github or in docs")
+	}
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return "", nil, err
+	}
+	files, err := core.MustInitGit(root).ListTrackedFiles()
+	if err != nil {
+		return "", nil, err
+	}
+	rules := parseCodeOwnerRules(string(data))
+	problems := lintCodeOwnerRules(cfg, rules, files)
+	if gh != nil {
+		handleProblems, err := gh.checkCodeOwnerHandles(rules)
+		if err != nil {
+			return "", nil, err
+		}
+		problems = append(problems, handleProblems...)
+	}
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].Line < problems[j].Line
+	})
+	return strings.TrimPrefix(filePath, root+"/"), problems, nil
+}
+
+// lintCodeOwnerRules checks the syntax of the rules, the patterns that match no file, the rules that never
+// apply since a later rule matches the same files and the emails missing from the users of the config.
+func lintCodeOwnerRules(cfg *core.Configuration, rules []CodeOwnerRule, files []string) (problems []CodeOwnersProblem) {
+	// the rule applying to each file, the last matching one.
+	lastMatches := make([]int, len(files))
+	matches := make([][]int, len(rules))
+	for i, f := range files {
+		lastMatches[i] = -1
+		for r, rule := range rules {
+			if matchesCodeOwnerRules(rule.Pattern, f) {
+				lastMatches[i] = r
+				matches[r] = append(matches[r], i)
+			}
+		}
+	}
+
+	for r, rule := range rules {
+		addProblem := func(format string, args ...interface{}) {
+			problems = append(problems, CodeOwnersProblem{Line: rule.Line, Message: fmt.Sprintf(format, args...)})
+		}
+		if strings.HasPrefix(rule.Pattern, "!") {
+			addProblem("negated pattern '%v' is not supported", rule.Pattern)
+		} else if strings.ContainsAny(rule.Pattern, "[]") {
+			addProblem("character ranges in '%v' are not supported", rule.Pattern)
+		}
+		for _, owner := range rule.Owners {
+			switch {
+			case codeOwnerUsername.MatchString(owner), codeOwnerTeam.MatchString(owner):
+			case codeOwnerEmail.MatchString(owner):
+				if !isConfiguredOwner(cfg, owner) {
+					addProblem("'%v' is not in the users of the config", owner)
+				}
+			default:
+				addProblem("invalid owner '%v', must be @username, @org/team or an email", owner)
+			}
+		}
+
+		duplicate := -1
+		for later := r + 1; later < len(rules); later++ {
+			if rules[later].Pattern == rule.Pattern {
+				duplicate = later
+			}
+		}
+		switch {
+		case duplicate >= 0:
+			addProblem("'%v' is repeated on line %v which takes precedence", rule.Pattern, rules[duplicate].Line)
+		case len(matches[r]) == 0:
+			addProblem("'%v' does not match any tracked file", rule.Pattern)
+		default:
+			shadowing := map[int]bool{}
+			for _, f := range matches[r] {
+				shadowing[lastMatches[f]] = true
+			}
+			if !shadowing[r] {
+				var lines []int
+				for later := range shadowing {
+					lines = append(lines, rules[later].Line)
+				}
+				sort.Ints(lines)
+				addProblem("'%v' never applies, its files are matched by the later rules on line %v", rule.Pattern, strings.Replace(strings.Trim(fmt.Sprint(lines), "[]"), " ", ", ", -1))
+			}
+		}
+	}
+	return problems
+}
+
+// checkCodeOwnerHandles checks that the users are members of the organization and that the teams exist.
+func (gh *GitHub) checkCodeOwnerHandles(rules []CodeOwnerRule) (problems []CodeOwnersProblem, err error) {
+	ctx := context.Background()
+	org := gh.cfg.GitHub.Organization
+	members := map[string]bool{}
+	var teams map[string]bool
+	for _, r := range rules {
+		for _, owner := range r.Owners {
+			handle := strings.ToLower(strings.TrimPrefix(owner, "@"))
+			switch {
+			case codeOwnerTeam.MatchString(owner):
+				if teams == nil {
+					if teams, err = gh.listTeamSlugs(ctx); err != nil {
+						return nil, err
+					}
+				}
+				parts := strings.SplitN(handle, "/", 2)
+				if !strings.EqualFold(parts[0], org) {
+					problems = append(problems, CodeOwnersProblem{Line: r.Line, Message: fmt.Sprintf("team '%v' is not in the organization '%v'", owner, org)})
+				} else if !teams[parts[1]] {
+					problems = append(problems, CodeOwnersProblem{Line: r.Line, Message: fmt.Sprintf("team '%v' does not exist", owner)})
+				}
+			case codeOwnerUsername.MatchString(owner):
+				isMember, ok := members[handle]
+				if !ok {
+					if isMember, _, err = gh.client.Organizations.IsMember(ctx, org, handle); err != nil {
+						return nil, err
+					}
+					members[handle] = isMember
+				}
+				if !isMember {
+					problems = append(problems, CodeOwnersProblem{Line: r.Line, Message: fmt.Sprintf("'%v' is not a member of the organization '%v'", owner, org)})
+				}
+			}
+		}
+	}
+	return problems, nil
+}
+
+func (gh *GitHub) listTeamSlugs(ctx context.Context) (map[string]bool, error) {
+	slugs := map[string]bool{}
+	opt := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := gh.client.Organizations.ListTeams(ctx, gh.cfg.GitHub.Organization, opt)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range page {
+			slugs[strings.ToLower(t.GetSlug())] = true
+		}
+		if resp.NextPage == 0 {
+			return slugs, nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
@@ -32,16 +32,22 @@ func MustInitGitHub(cfg *core.Configuration) *GitHub {
 	)
 	tc := oauth2.NewClient(ctx, ts)
 
-	client := github.NewClient(tc)
+	if cfg.GitHubHost() == "github.com" {
+		return &GitHub{cfg, github.NewClient(tc)}
+	}
+	client, err := github.NewEnterpriseClient(cfg.GitHubAPIURL()+"/", cfg.GitHubURL("api", "uploads")+"/", tc)
+	if err != nil {
+		log.Fatalf("Failed to create the GitHub Enterprise client: %v", err)
+	}
 	return &GitHub{cfg, client}
 }
 
 func mustLoadGitHubToken(cfg *core.Configuration) {
-	err := core.LoadKeyringItem("GitHub User", &cfg.GitHub.Username)
+	err := cfg.LoadStoredCredential("GitHub User", &cfg.GitHub.Username)
 	if err != nil {
 		log.Fatalf("Failed to set GitHub User: %v", err)
 	}
-	err = core.LoadKeyringItem("GitHub Token", &cfg.GitHub.Token)
+	err = cfg.LoadStoredCredential("GitHub Token", &cfg.GitHub.Token)
 	if err != nil {
 		log.Fatalf("Failed to set GitHub Token: %v", err)
 	}
@@ -52,13 +58,13 @@ func MustSetupGitHub(cfg *core.Configuration) {
 		"Create a new GitHub Token. " +
 			"Grant 'Full control of private repositories'.\n" +
 			"Open the GitHub new token page?") {
-		utils.OpenURI("https://github.com/settings/tokens/new")
+		utils.OpenURI(cfg.GitHubURL("settings", "tokens", "new"))
 	}
 	mustLoadGitHubToken(cfg)
 }
 
 func (gh *GitHub) CreatePR(title, body, repoDir string) error {
-	g := core.MustInitGit(repoDir)
+	g := core.MustInitGit(repoDir).WithConfig(gh.cfg)
 	err := g.Push(gh.cfg)
 	if err != nil {
 		return err
@@ -68,9 +74,9 @@ func (gh *GitHub) CreatePR(title, body, repoDir string) error {
 		return err
 	}
 	branch := g.GetCurrentBranch()
-	base := "master"
+	base := g.BaseBranch()
 	if title == "" {
-		subjects := g.LogNotInMasterSubjects()
+		subjects := g.LogNotInBaseSubjects()
 		if len(subjects) == 1 {
 			title = subjects[0]
 		} else {
@@ -79,7 +85,7 @@ func (gh *GitHub) CreatePR(title, body, repoDir string) error {
 	}
 
 	if body == "" {
-		body = g.LogNotInMasterBody()
+		body = g.LogNotInBaseBody()
 	}
 
 	root, err := g.GetRepositoryRootPath()
@@ -136,11 +142,18 @@ func (gh *GitHub) CreatePR(title, body, repoDir string) error {
 
 func (gh *GitHub) OpenPage(m *core.Manifest, p ...string) error {
 	base := []string{
-		"https://github.com",
 		gh.cfg.GitHub.Organization,
 		m.Repository,
 	}
-	return utils.OpenURI(append(base, p...)...)
+	return utils.OpenURI(gh.cfg.GitHubURL(append(base, p...)...))
+}
+
+// OpenRepository opens the repository, or the directory of the manifest in a monorepo.
+func (gh *GitHub) OpenRepository(m *core.Manifest) error {
+	if m.Dir != "" {
+		return gh.OpenPage(m, "tree", core.InitGit().WithConfig(gh.cfg).BaseBranch(), m.Dir)
+	}
+	return gh.OpenPage(m)
 }
 
 func (gh *GitHub) OpenPR(m *core.Manifest, pr string) error {
@@ -156,7 +169,7 @@ func (gh *GitHub) OpenCompareCommitsPage(m *core.Manifest, commit *core.GitCommi
 }
 
 func (gh *GitHub) OpenCompareBranchPage(m *core.Manifest) error {
-	return gh.OpenPage(m, "compare", "master..."+m.Branch)
+	return gh.OpenPage(m, "compare", core.InitGit().WithConfig(gh.cfg).BaseBranch()+"..."+m.Branch)
 }
 
 func (gh *GitHub) ListBranches(maxAge int) error {
@@ -191,7 +204,7 @@ func (gh *GitHub) ListBranches(maxAge int) error {
 			pullRequests[*pr.Head.SHA] = pr
 		}
 		for _, b := range branches {
-			if *b.Name == "master" {
+			if *b.Name == r.GetDefaultBranch() {
 				continue
 			}
 			b, _, err := gh.client.Repositories.GetBranch(ctx, org, *r.Name, url.PathEscape(*b.Name))
@@ -215,7 +228,7 @@ func (gh *GitHub) ListBranches(maxAge int) error {
 	for author, branches := range authors {
 		fmt.Println("\n" + author)
 		for _, b := range branches {
-			fmt.Printf("https://github.com/%v/%v/branches/yours %v %v\n", org, b.Repository, b.Branch, b.PRURL)
+			fmt.Printf("%v %v %v\n", gh.cfg.GitHubURL(org, b.Repository, "branches", "yours"), b.Branch, b.PRURL)
 		}
 	}
 	return nil
@@ -0,0 +1,100 @@
+package github
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"path"
+	"sort"
+
+	"github.com/google/go-github/github"
+	"github.com/j-martin/bub/core"
+)
+
+// ListOrganizationRepositories lists the repositories of the configured organization, forks and archived excluded.
+func (gh *GitHub) ListOrganizationRepositories() ([]*github.Repository, error) {
+	ctx := context.Background()
+	opt := &github.RepositoryListByOrgOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	var repos []*github.Repository
+	for {
+		page, resp, err := gh.client.Repositories.ListByOrg(ctx, gh.cfg.GitHub.Organization, opt)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range page {
+			if r.GetFork() || r.GetArchived() {
+				continue
+			}
+			repos = append(repos, r)
+		}
+		if resp.NextPage == 0 {
+			return repos, nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
+
+// ListManifests fetches the manifests of every repository of the organization through the API, including
+// the nested manifests of the monorepos. The repositories without manifest are skipped.
+func (gh *GitHub) ListManifests() (core.Manifests, error) {
+	ctx := context.Background()
+	repos, err := gh.ListOrganizationRepositories()
+	if err != nil {
+		return nil, err
+	}
+	var manifests core.Manifests
+	for _, r := range repos {
+		repoManifests, err := gh.getManifests(ctx, r)
+		if err != nil {
+			return nil, err
+		}
+		manifests = append(manifests, repoManifests...)
+	}
+	sort.Sort(manifests)
+	return manifests, nil
+}
+
+// getManifests finds the manifests in the tree of the default branch, the invalid ones are skipped.
+func (gh *GitHub) getManifests(ctx context.Context, repo *github.Repository) (core.Manifests, error) {
+	org, name, branch := gh.cfg.GitHub.Organization, repo.GetName(), repo.GetDefaultBranch()
+	if branch == "" {
+		branch = "HEAD"
+	}
+	tree, resp, err := gh.client.Git.GetTree(ctx, org, name, branch, true)
+	// the empty repositories have no tree.
+	if resp != nil && (resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusConflict) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var filePaths []string
+	for _, e := range tree.Entries {
+		if e.GetType() == "blob" {
+			filePaths = append(filePaths, e.GetPath())
+		}
+	}
+	var manifests core.Manifests
+	for _, manifestPath := range core.SelectManifestPaths(filePaths) {
+		opt := &github.RepositoryContentGetOptions{Ref: branch}
+		file, _, _, err := gh.client.Repositories.GetContents(ctx, org, name, manifestPath, opt)
+		if err != nil {
+			return nil, err
+		}
+		content, err := file.GetContent()
+		if err != nil {
+			return nil, err
+		}
+		manifestDir := path.Dir(manifestPath)
+		if manifestDir == "." {
+			manifestDir = ""
+		}
+		m, err := core.ParseRepositoryManifest(name, manifestDir, []byte(content))
+		if err != nil {
+			log.Printf("Skipping the invalid manifest %v", err)
+			continue
+		}
+		manifests = append(manifests, *m)
+	}
+	return manifests, nil
+}
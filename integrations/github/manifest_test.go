@@ -0,0 +1,61 @@
+package github
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-github/github"
+	"github.com/j-martin/bub/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetManifests(t *testing.T) {
+	t.Parallel()
+	contents := map[string]string{
+		".bench.yml":                  "name: platform\ntypes: [library]\n",
+		"services/billing/.bench.yml": "types: [service]\n",
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/benchlabs/platform/git/trees/main", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"tree": [
+			{"path": ".bench.yml", "type": "blob"},
+			{"path": "services", "type": "tree"},
+			{"path": "services/billing/.bench.yml", "type": "blob"},
+			{"path": "services/billing/main.go", "type": "blob"}
+		]}`)
+	})
+	mux.HandleFunc("/repos/benchlabs/platform/contents/", func(w http.ResponseWriter, r *http.Request) {
+		content, ok := contents[r.URL.Path[len("/repos/benchlabs/platform/contents/"):]]
+		if !ok || r.URL.Query().Get("ref") != "main" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `{"type": "file", "encoding": "base64", "content": "%v"}`, base64.StdEncoding.EncodeToString([]byte(content)))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	cfg := &core.Configuration{}
+	cfg.GitHub.Organization = "benchlabs"
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	gh := &GitHub{cfg: cfg, client: client}
+
+	manifests, err := gh.getManifests(context.Background(), &github.Repository{Name: github.String("platform"), DefaultBranch: github.String("main")})
+	assert.NoError(t, err)
+	var names, dirs []string
+	for _, m := range manifests {
+		names, dirs = append(names, m.Name), append(dirs, m.Dir)
+	}
+	assert.Equal(t, []string{"platform", "billing"}, names)
+	assert.Equal(t, []string{"", "services/billing"}, dirs)
+
+	manifests, err = gh.getManifests(context.Background(), &github.Repository{Name: github.String("empty")})
+	assert.NoError(t, err)
+	assert.Empty(t, manifests)
+}
@@ -0,0 +1,277 @@
+package github
+
+import (
+	"fmt"
+	"github.com/j-martin/bub/core"
+	"github.com/j-martin/bub/utils"
+	"io"
+	"io/ioutil"
+	"math"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// ownershipHalfLife is the age at which a change counts for half, the recent changes weigh more.
+const ownershipHalfLife = 90 * 24 * time.Hour
+
+// minOwnerShare is the minimum score of an owner, relative to the main contributor of the directory.
+const minOwnerShare = 0.25
+
+var noReplyEmail = regexp.MustCompile(`^(\d+\+)?([^@]+)@users\.noreply\.github\.com$`)
+
+// SuggestOwnersOptions tunes how the history is analyzed.
+type SuggestOwnersOptions struct {
+	// Since is passed to git log, e.g. '1 year ago'.
+	Since string
+	// Depth is the number of directory levels, e.g. 1 for the top-level directories only.
+	Depth     int
+	MaxOwners int
+	// ActiveDays is the period during which a contributor is considered active.
+	ActiveDays int
+}
+
+// OwnerSuggestion is the owners suggested for a directory from its history.
+type OwnerSuggestion struct {
+	// Dir is relative to the root of the repository, empty for the root.
+	Dir           string
+	Owners        []string
+	CurrentOwners []string
+	// ActiveContributors changed the directory during the active period.
+	ActiveContributors []string
+}
+
+// Pattern returns the CODEOWNERS pattern of the directory.
+func (s OwnerSuggestion) Pattern() string {
+	if s.Dir == "" {
+		return "*"
+	}
+	return "/" + s.Dir + "/"
+}
+
+type OwnersSuggestion struct {
+	Directories []OwnerSuggestion
+	// UnmappedAuthors are missing from the users of the config, they are suggested by email.
+	UnmappedAuthors []string
+	// Diff is the suggested change of the CODEOWNERS file, empty if there is none.
+	Diff string
+}
+
+// SuggestOwners suggests the owners of each directory from the authors of the changes, weighted by
+// recency and churn. The authors are mapped to their GitHub username with the users of the config.
+func SuggestOwners(cfg *core.Configuration, opts SuggestOwnersOptions) (*OwnersSuggestion, error) {
+	root, err := core.MustInitGit("").GetRepositoryRootPath()
+	if err != nil {
+		return nil, err
+	}
+	g := core.MustInitGit(root)
+	changes, err := g.LogFileChanges(opts.Since)
+	if err != nil {
+		return nil, err
+	}
+	tracked, err := g.ListTrackedFiles()
+	if err != nil {
+		return nil, err
+	}
+	var content string
+	codeOwnersFile, isNew := ".github/CODEOWNERS", true
+	filePath, err := findCodeOwnersFile(root)
+	if err == nil {
+		data, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+		content = string(data)
+		codeOwnersFile, isNew = strings.TrimPrefix(filePath, root+"/"), false
+	} else if err != utils.FileDoesNotExist {
+		return nil, err
+	}
+	suggestion := suggestOwners(cfg, parseCodeOwnerRules(content), trackedFileChanges(changes, tracked), opts, time.Now())
+	suggestion.Diff, err = utils.UnifiedDiff(codeOwnersFile, content, suggestedCodeOwners(content, suggestion.Directories), isNew)
+	return suggestion, err
+}
+
+// trackedFileChanges drops the changes of the files deleted or renamed since, their directories would
+// get rules matching no file.
+func trackedFileChanges(changes []core.GitFileChange, tracked []string) (filtered []core.GitFileChange) {
+	files := map[string]bool{}
+	for _, f := range tracked {
+		files[f] = true
+	}
+	for _, c := range changes {
+		if files[c.File] {
+			filtered = append(filtered, c)
+		}
+	}
+	return filtered
+}
+
+type contributor struct {
+	score      float64
+	lastChange time.Time
+}
+
+func suggestOwners(cfg *core.Configuration, rules []CodeOwnerRule, changes []core.GitFileChange, opts SuggestOwnersOptions, now time.Time) *OwnersSuggestion {
+	dirs := map[string]map[string]*contributor{}
+	unmapped := map[string]bool{}
+	for _, c := range changes {
+		owner, mapped := authorOwner(cfg, c.Author, c.Email)
+		if !mapped {
+			unmapped[owner] = true
+		}
+		dir := ownerDirectory(c.File, opts.Depth)
+		if dirs[dir] == nil {
+			dirs[dir] = map[string]*contributor{}
+		}
+		if dirs[dir][owner] == nil {
+			dirs[dir][owner] = &contributor{}
+		}
+		ct := dirs[dir][owner]
+		recency := math.Pow(0.5, float64(now.Sub(c.Time))/float64(ownershipHalfLife))
+		ct.score += (1 + math.Log1p(float64(c.Lines))) * recency
+		if c.Time.After(ct.lastChange) {
+			ct.lastChange = c.Time
+		}
+	}
+
+	suggestion := &OwnersSuggestion{}
+	activeSince := now.AddDate(0, 0, -opts.ActiveDays)
+	for dir, contributors := range dirs {
+		s := OwnerSuggestion{Dir: dir, CurrentOwners: resolveCodeOwners(rules, dir+"/")}
+		var all []string
+		for owner, ct := range contributors {
+			all = append(all, owner)
+			if ct.lastChange.After(activeSince) {
+				s.ActiveContributors = append(s.ActiveContributors, owner)
+			}
+		}
+		sort.Strings(s.ActiveContributors)
+		candidates := s.ActiveContributors
+		if len(candidates) == 0 {
+			candidates = all
+		}
+		candidates = append([]string{}, candidates...)
+		sort.Slice(candidates, func(i, j int) bool {
+			if contributors[candidates[i]].score != contributors[candidates[j]].score {
+				return contributors[candidates[i]].score > contributors[candidates[j]].score
+			}
+			return candidates[i] < candidates[j]
+		})
+		for i, owner := range candidates {
+			if i >= opts.MaxOwners || contributors[owner].score < minOwnerShare*contributors[candidates[0]].score {
+				break
+			}
+			s.Owners = append(s.Owners, owner)
+		}
+		suggestion.Directories = append(suggestion.Directories, s)
+	}
+	sort.Slice(suggestion.Directories, func(i, j int) bool {
+		return suggestion.Directories[i].Dir < suggestion.Directories[j].Dir
+	})
+	for author := range unmapped {
+		suggestion.UnmappedAuthors = append(suggestion.UnmappedAuthors, author)
+	}
+	sort.Strings(suggestion.UnmappedAuthors)
+	return suggestion
+}
+
+// authorOwner returns the @username of the author, or the email if the author is not in the users of the config.
+func authorOwner(cfg *core.Configuration, name, email string) (string, bool) {
+	u := core.User{Name: name, Email: email}
+	cfg.PopulateUser(&u)
+	if u.GitHub != "" {
+		return "@" + u.GitHub, true
+	}
+	if m := noReplyEmail.FindStringSubmatch(email); m != nil {
+		return "@" + m[2], true
+	}
+	return email, false
+}
+
+// ownerDirectory returns the directory of the file, truncated to the depth. Empty for the root.
+func ownerDirectory(file string, depth int) string {
+	dir := path.Dir(file)
+	if dir == "." {
+		return ""
+	}
+	parts := strings.Split(dir, "/")
+	if len(parts) > depth {
+		parts = parts[:depth]
+	}
+	return strings.Join(parts, "/")
+}
+
+func sameOwners(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a, b = append([]string{}, a...), append([]string{}, b...)
+	sort.Strings(a)
+	sort.Strings(b)
+	return strings.EqualFold(strings.Join(a, " "), strings.Join(b, " "))
+}
+
+// suggestedCodeOwners returns the content of the CODEOWNERS with the suggestions. The existing rule of
+// a directory is replaced, otherwise the rule is added before the rules of its subdirectories
+// since the last matching rule takes precedence.
+func suggestedCodeOwners(content string, suggestions []OwnerSuggestion) string {
+	var lines []string
+	if content != "" {
+		lines = strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	}
+	for _, s := range suggestions {
+		if len(s.Owners) == 0 || sameOwners(s.Owners, s.CurrentOwners) {
+			continue
+		}
+		rule := s.Pattern() + " " + strings.Join(s.Owners, " ")
+		existing, insertAt := -1, len(lines)
+		for i, line := range lines {
+			rules := parseCodeOwnerRules(line)
+			if len(rules) == 0 {
+				continue
+			}
+			pattern := strings.Trim(rules[0].Pattern, "/")
+			if (s.Dir == "" && pattern == "*") || (s.Dir != "" && pattern == s.Dir) {
+				existing = i
+			} else if insertAt == len(lines) && (s.Dir == "" || strings.HasPrefix(pattern, s.Dir+"/")) {
+				insertAt = i
+			}
+		}
+		if existing >= 0 {
+			lines[existing] = rule
+			continue
+		}
+		lines = append(lines[:insertAt], append([]string{rule}, lines[insertAt:]...)...)
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// WriteOwnersSuggestion writes the suggested owners of each directory and the directories at risk.
+func WriteOwnersSuggestion(w io.Writer, suggestion *OwnersSuggestion) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "Directory\tSuggested\tCurrent\tActive contributors")
+	for _, s := range suggestion.Directories {
+		fmt.Fprintf(table, "%v\t%v\t%v\t%v\n", s.Pattern(), strings.Join(s.Owners, " "), strings.Join(s.CurrentOwners, " "), len(s.ActiveContributors))
+	}
+	if err := table.Flush(); err != nil {
+		return err
+	}
+	for _, s := range suggestion.Directories {
+		switch len(s.ActiveContributors) {
+		case 0:
+			fmt.Fprintf(w, "Warning: %v has no active contributor.\n", s.Pattern())
+		case 1:
+			fmt.Fprintf(w, "Warning: %v has a single active contributor, %v.\n", s.Pattern(), s.ActiveContributors[0])
+		}
+	}
+	if len(suggestion.UnmappedAuthors) > 0 {
+		fmt.Fprintf(w, "\nAuthors missing from the users of the config, add them to use their GitHub username:\n  %v\n", strings.Join(suggestion.UnmappedAuthors, "\n  "))
+	}
+	return nil
+}
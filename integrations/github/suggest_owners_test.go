@@ -0,0 +1,86 @@
+package github
+
+import (
+	"github.com/j-martin/bub/core"
+	"github.com/j-martin/bub/utils"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestSuggestOwners(t *testing.T) {
+	now := time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC)
+	daysAgo := func(days int) time.Time {
+		return now.AddDate(0, 0, -days)
+	}
+	cfg := &core.Configuration{Users: []core.User{
+		{GitHub: "alice", Email: "alice@example.com"},
+		{GitHub: "bob", Name: "Bob"},
+	}}
+	changes := []core.GitFileChange{
+		{Author: "Alice", Email: "alice@example.com", Time: daysAgo(5), File: "billing/api/main.go", Lines: 200},
+		{Author: "Bob", Email: "bob@home.example.com", Time: daysAgo(10), File: "billing/main.go", Lines: 120},
+		{Author: "Carol", Email: "carol@example.com", Time: daysAgo(20), File: "billing/main.go", Lines: 1},
+		{Author: "Dave", Email: "1234+dave@users.noreply.github.com", Time: daysAgo(300), File: "legacy/old.go", Lines: 50},
+		{Author: "Alice", Email: "alice@example.com", Time: daysAgo(1), File: "web/app.js", Lines: 10},
+	}
+	rules := parseCodeOwnerRules("* @alice\n/web/ @alice\n/billing/api/ @bob\n")
+	suggestion := suggestOwners(cfg, rules, changes, SuggestOwnersOptions{Depth: 1, MaxOwners: 3, ActiveDays: 90}, now)
+
+	assert.Equal(t, []OwnerSuggestion{
+		{Dir: "billing", Owners: []string{"@alice", "@bob"}, CurrentOwners: []string{"@alice"}, ActiveContributors: []string{"@alice", "@bob", "carol@example.com"}},
+		{Dir: "legacy", Owners: []string{"@dave"}, CurrentOwners: []string{"@alice"}},
+		{Dir: "web", Owners: []string{"@alice"}, CurrentOwners: []string{"@alice"}, ActiveContributors: []string{"@alice"}},
+	}, suggestion.Directories)
+	assert.Equal(t, []string{"carol@example.com"}, suggestion.UnmappedAuthors)
+
+	content := "# owners\n* @alice\n/web/ @alice\n/billing/api/ @bob\n"
+	assert.Equal(t, "# owners\n* @alice\n/web/ @alice\n/billing/ @alice @bob\n/billing/api/ @bob\n/legacy/ @dave\n",
+		suggestedCodeOwners(content, suggestion.Directories))
+	diff, err := utils.UnifiedDiff("CODEOWNERS", "", suggestedCodeOwners("", suggestion.Directories), true)
+	assert.NoError(t, err)
+	assert.Contains(t, diff, "--- /dev/null\n+++ b/CODEOWNERS\n@@ -0,0 +1,2 @@\n+/billing/ @alice @bob\n+/legacy/ @dave\n")
+}
+
+func TestSuggestOwnersSkipsUntrackedFiles(t *testing.T) {
+	now := time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC)
+	changes := []core.GitFileChange{
+		{Author: "Alice", Email: "alice@example.com", Time: now, File: "old-web/app.js", Lines: 10},
+		{Author: "Alice", Email: "alice@example.com", Time: now, File: "web/app.js", Lines: 10},
+		{Author: "Bob", Email: "bob@example.com", Time: now, File: "legacy/old.go", Lines: 50},
+	}
+	cfg := &core.Configuration{Users: []core.User{{GitHub: "alice", Email: "alice@example.com"}}}
+	tracked := []string{"README.md", "web/app.js"}
+	suggestion := suggestOwners(cfg, nil, trackedFileChanges(changes, tracked), SuggestOwnersOptions{Depth: 1, MaxOwners: 3, ActiveDays: 90}, now)
+
+	assert.Equal(t, []OwnerSuggestion{
+		{Dir: "web", Owners: []string{"@alice"}, ActiveContributors: []string{"@alice"}},
+	}, suggestion.Directories)
+	assert.Empty(t, suggestion.UnmappedAuthors)
+	assert.Equal(t, "/web/ @alice\n", suggestedCodeOwners("", suggestion.Directories))
+}
+
+func TestSuggestedCodeOwnersDiffApplies(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bub")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	assert.NoError(t, core.MustInitGit(dir).RunGit("init", "-q"))
+	// without a trailing newline, the patch must end with '\ No newline at end of file'.
+	content := "* @alice\n/web/ @alice"
+	assert.NoError(t, os.MkdirAll(path.Join(dir, ".github"), 0700))
+	assert.NoError(t, ioutil.WriteFile(path.Join(dir, ".github/CODEOWNERS"), []byte(content), 0600))
+	updated := suggestedCodeOwners(content, []OwnerSuggestion{{Dir: "billing", Owners: []string{"@bob"}}})
+
+	diff, err := utils.UnifiedDiff(".github/CODEOWNERS", content, updated, false)
+	assert.NoError(t, err)
+	assert.Contains(t, diff, "\\ No newline at end of file")
+	patch := path.Join(dir, "owners.patch")
+	assert.NoError(t, ioutil.WriteFile(patch, []byte(diff), 0600))
+	assert.NoError(t, core.MustInitGit(dir).RunGit("apply", patch))
+	applied, err := ioutil.ReadFile(path.Join(dir, ".github/CODEOWNERS"))
+	assert.NoError(t, err)
+	assert.Equal(t, updated, string(applied))
+}
@@ -0,0 +1,68 @@
+package github
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/github"
+	"github.com/j-martin/bub/core"
+)
+
+// RepositoryFilter selects the repositories of the organization, e.g. to clone them.
+type RepositoryFilter struct {
+	// Topics must all be set on the repository.
+	Topics []string
+	// ManifestType is matched against the type of the repository's manifest, the repositories without
+	// manifest are excluded when set.
+	ManifestType string
+}
+
+// ListRepositoryNames lists the repositories of the organization matching the filter, forks and archived excluded.
+func (gh *GitHub) ListRepositoryNames(filter RepositoryFilter) ([]string, error) {
+	repos, err := gh.ListOrganizationRepositories()
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	var names []string
+	for _, r := range filterRepositoriesByTopics(repos, filter.Topics) {
+		if filter.ManifestType != "" {
+			manifests, err := gh.getManifests(ctx, r)
+			if err != nil {
+				return nil, err
+			}
+			if len(manifests.Filter(core.ManifestFilter{Type: filter.ManifestType})) == 0 {
+				continue
+			}
+		}
+		names = append(names, r.GetName())
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func filterRepositoriesByTopics(repos []*github.Repository, topics []string) (filtered []*github.Repository) {
+	for _, r := range repos {
+		if hasTopics(r.Topics, topics) {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
+
+func hasTopics(repoTopics, topics []string) bool {
+	for _, t := range topics {
+		found := false
+		for _, rt := range repoTopics {
+			if strings.EqualFold(rt, t) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,24 @@
+package github
+
+import (
+	"testing"
+
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterRepositoriesByTopics(t *testing.T) {
+	t.Parallel()
+	repos := []*github.Repository{
+		{Name: github.String("billing"), Topics: []string{"payments", "go"}},
+		{Name: github.String("ledger"), Topics: []string{"Payments"}},
+		{Name: github.String("web")},
+	}
+	var names []string
+	for _, r := range filterRepositoriesByTopics(repos, []string{"payments"}) {
+		names = append(names, r.GetName())
+	}
+	assert.Equal(t, []string{"billing", "ledger"}, names)
+	assert.Len(t, filterRepositoriesByTopics(repos, []string{"payments", "go"}), 1)
+	assert.Len(t, filterRepositoriesByTopics(repos, nil), 3)
+}
@@ -51,7 +51,7 @@ func mustLoadVaultCredentials(cfg *core.Configuration) {
 	if cfg.Vault.AuthMethod == "" {
 		cfg.Vault.AuthMethod = "Okta"
 	}
-	err := core.LoadCredentials("Vault/"+cfg.Vault.AuthMethod, &cfg.Vault.Username, &cfg.Vault.Password, cfg.ResetCredentials)
+	err := cfg.LoadCredentials("Vault/"+cfg.Vault.AuthMethod, &cfg.Vault.Username, &cfg.Vault.Password)
 	if err != nil {
 		log.Fatalf("Failed to set Vault credentials: %v", err)
 	}
@@ -2,16 +2,21 @@ package main
 
 import (
 	"github.com/j-martin/bub/cmd"
+	"github.com/j-martin/bub/core"
 	"github.com/urfave/cli"
 	"os"
 )
 
 func main() {
+	cfg := &core.Configuration{}
+	manifest := &core.Manifest{}
 	app := cli.NewApp()
 	app.Name = "bub"
 	app.Usage = "A tool for all your Bench related needs."
 	app.Version = "1.0.0"
 	app.EnableBashCompletion = true
-	app.Commands = cmd.BuildCmds()
+	app.Flags = cmd.BuildFlags()
+	app.Before = cmd.BuildBefore(cfg, manifest)
+	app.Commands = cmd.BuildCmds(cfg, manifest)
 	app.Run(os.Args)
 }
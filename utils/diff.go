@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+)
+
+// UnifiedDiff returns the changes of the file as a patch which can be applied with 'git apply', using
+// 'git diff --no-index' as 'bub config --load-shared-config' does. name is the path of the file in the
+// repository, isNew when it does not exist yet.
+func UnifiedDiff(name, from, to string, isNew bool) (string, error) {
+	if from == to {
+		return "", nil
+	}
+	dir, err := ioutil.TempDir("", "bub-diff")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+	fromPath, toPath := "/dev/null", path.Join("b", name)
+	if !isNew {
+		fromPath = path.Join("a", name)
+		if err = writeDiffFile(dir, fromPath, from); err != nil {
+			return "", err
+		}
+	}
+	if err = writeDiffFile(dir, toPath, to); err != nil {
+		return "", err
+	}
+	command := exec.Command("git", "diff", "--no-index", "--no-prefix", "--no-color", fromPath, toPath)
+	command.Dir = dir
+	output, err := command.Output()
+	// git returns a non-zero exit code when the files differ.
+	if _, ok := err.(*exec.ExitError); ok && len(output) > 0 {
+		err = nil
+	}
+	return string(output), err
+}
+
+func writeDiffFile(dir, name, content string) error {
+	if err := os.MkdirAll(path.Join(dir, path.Dir(name)), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path.Join(dir, name), []byte(content), 0600)
+}
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// RunCmdContext is RunCmd within the context, see runCmdContext.
+func RunCmdContext(ctx context.Context, cmd string, args ...string) error {
+	command := exec.Command(cmd, args...)
+	command.Stdout = os.Stdout
+	command.Stderr = os.Stderr
+	return runCmdContext(ctx, command)
+}
+
+// RunCmdWithStdoutContext is RunCmdWithStdout within the context, see runCmdContext.
+func RunCmdWithStdoutContext(ctx context.Context, cmd string, args ...string) (string, error) {
+	command := exec.Command(cmd, args...)
+	var buf bytes.Buffer
+	command.Stdout = &buf
+	command.Stderr = os.Stderr
+	err := runCmdContext(ctx, command)
+	return strings.Trim(buf.String(), "\n"), err
+}
+
+// RunCmdWithFullOutputContext is RunCmdWithFullOutput within the context, see runCmdContext.
+func RunCmdWithFullOutputContext(ctx context.Context, cmd string, args ...string) (string, error) {
+	command := exec.Command(cmd, args...)
+	var buf bytes.Buffer
+	command.Stderr = &buf
+	command.Stdout = &buf
+	err := runCmdContext(ctx, command)
+	return strings.Join(args, " ") + "\n" + strings.Trim(buf.String(), "\n"), err
+}
+
+// runCmdContext runs the command in its own process group, so that a Ctrl-C in the terminal lets it
+// finish, and kills it with its children (e.g. ssh) when the context is done. Outside of the foreground
+// process group, a prompt would hang until the timeout, so git and ssh are set to fail instead of asking,
+// see nonInteractiveEnv.
+func runCmdContext(ctx context.Context, command *exec.Cmd) error {
+	setProcessGroup(command)
+	command.Env = nonInteractiveEnv(os.Environ())
+	if err := command.Start(); err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- command.Wait()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		killProcessGroup(command)
+		<-done
+		return ctx.Err()
+	}
+}
+
+// nonInteractiveEnv disables the git credential prompts and the ssh prompts (e.g. the host key
+// confirmation or the key passphrase), on top of the GIT_SSH_COMMAND of the user if any. A custom
+// GIT_SSH program is left as is.
+func nonInteractiveEnv(env []string) []string {
+	sshCommand, hasSSHCommand, hasSSHProgram := "ssh", false, false
+	var filtered []string
+	for _, e := range env {
+		if strings.HasPrefix(e, "GIT_SSH_COMMAND=") {
+			sshCommand, hasSSHCommand = strings.TrimPrefix(e, "GIT_SSH_COMMAND="), true
+			continue
+		}
+		hasSSHProgram = hasSSHProgram || strings.HasPrefix(e, "GIT_SSH=")
+		filtered = append(filtered, e)
+	}
+	filtered = append(filtered, "GIT_TERMINAL_PROMPT=0")
+	if hasSSHProgram && !hasSSHCommand {
+		return filtered
+	}
+	return append(filtered, "GIT_SSH_COMMAND="+sshCommand+" -o BatchMode=yes")
+}
@@ -0,0 +1,17 @@
+//go:build !windows
+// +build !windows
+
+package utils
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+func setProcessGroup(command *exec.Cmd) {
+	command.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+func killProcessGroup(command *exec.Cmd) {
+	syscall.Kill(-command.Process.Pid, syscall.SIGKILL)
+}
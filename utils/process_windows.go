@@ -0,0 +1,13 @@
+//go:build windows
+// +build windows
+
+package utils
+
+import "os/exec"
+
+// setProcessGroup is a no-op, the commands still receive the Ctrl-C of the console on Windows.
+func setProcessGroup(command *exec.Cmd) {}
+
+func killProcessGroup(command *exec.Cmd) {
+	command.Process.Kill()
+}
@@ -55,6 +55,14 @@ func RunCmdWithStdout(cmd string, args ...string) (string, error) {
 	return strings.Trim(string(output), "\n"), err
 }
 
+func RunCmdWithInput(input string, cmd string, args ...string) (string, error) {
+	command := exec.Command(cmd, args...)
+	command.Stdin = strings.NewReader(input)
+	command.Stderr = os.Stderr
+	output, err := command.Output()
+	return strings.Trim(string(output), "\n"), err
+}
+
 func RunCmdWithFullOutput(cmd string, args ...string) (string, error) {
 	command := exec.Command(cmd, args...)
 	var buf bytes.Buffer
@@ -11,6 +11,7 @@ import (
 	"os/exec"
 	"path"
 	"runtime"
+	"sort"
 	"strings"
 	"time"
 )
@@ -222,3 +223,23 @@ func Contains(str string, haystack ...string) bool {
 	}
 	return false
 }
+
+// SortedKeys returns the keys of the map in order.
+func SortedKeys(m map[string]string) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// SplitAndTrim splits the string and drops the empty items, e.g. "a, b," -> [a b]
+func SplitAndTrim(s, sep string) (items []string) {
+	for _, i := range strings.Split(s, sep) {
+		if i = strings.TrimSpace(i); i != "" {
+			items = append(items, i)
+		}
+	}
+	return items
+}